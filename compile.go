@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/snark"
+	"github.com/eugenekadish/cryptopalooza/snark/dsl"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// compile builds the circuit of a program of package dsl over the bn256
+// scalar field and reports the size of its R1CS, e.g.
+//
+//	go run . compile -inputs x=3,out=35 cube.zk
+//
+// The inputs are optional; if they are given the witness is solved and the
+// constraints are checked by dividing by the target polynomial of the QAP.
+func compile(args []string) error {
+
+	var err error
+
+	var flags = flag.NewFlagSet("compile", flag.ContinueOnError)
+
+	var ascii = flags.Bool("ascii", false, "print the gates and constraints")
+	var dot = flags.Bool("dot", false, "print the gates as a Graphviz digraph")
+	var inputs = flags.String("inputs", "", "comma separated name=value inputs to solve the circuit for")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: compile [-ascii] [-dot] [-inputs name=value,...] file")
+	}
+
+	var source []byte
+	if source, err = ioutil.ReadFile(flags.Arg(0)); err != nil {
+		return err
+	}
+
+	var f = field.New(bn256.Order)
+
+	var c *snark.Circuit
+	if c, err = dsl.Compile(f, string(source)); err != nil {
+		return fmt.Errorf("%s:%v", flags.Arg(0), err)
+	}
+
+	var r1cs = c.R1CS()
+
+	fmt.Printf("constraints %d, variables %d, public %d \n", len(r1cs.Constraints), len(r1cs.Variables), len(r1cs.Public()))
+	fmt.Printf("optimized   %s \n", r1cs.Optimize())
+
+	var q *qap.QAP
+	if q, err = qap.CompileNTT(r1cs); err != nil {
+		return err
+	}
+
+	fmt.Printf("domain      %d points \n", q.Domain.Size)
+
+	var witness []*field.Element
+
+	if *inputs != "" {
+
+		var values = make(map[string]*field.Element)
+
+		var input string
+		for _, input = range strings.Split(*inputs, ",") {
+
+			var pair = strings.SplitN(input, "=", 2)
+
+			var value, ok = new(big.Int).SetString(strings.TrimSpace(pair[len(pair)-1]), 10)
+			if len(pair) != 2 || !ok {
+				return fmt.Errorf("input %q is not name=value", input)
+			}
+
+			values[strings.TrimSpace(pair[0])] = f.NewElement(value)
+		}
+
+		if witness, err = c.Solve(values); err != nil {
+			return err
+		}
+
+		if _, err = q.Quotient(witness); err != nil {
+			return err
+		}
+
+		fmt.Printf("satisfied \n")
+	}
+
+	if *ascii {
+		fmt.Print(c.ASCII(witness))
+	}
+
+	if *dot {
+		fmt.Print(c.DOT(witness))
+	}
+
+	return nil
+}
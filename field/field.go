@@ -0,0 +1,254 @@
+package field
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Field is the prime field of integers modulo a prime, such as the order of
+// the bn256 groups.
+type Field struct {
+	modulus *big.Int
+}
+
+// New creates a field for the modulus. The modulus is copied, so later changes
+// to the argument (e.g. bn256.Order.Set) do not affect the field.
+func New(modulus *big.Int) *Field {
+	return &Field{modulus: new(big.Int).Set(modulus)}
+}
+
+// Modulus returns a copy of the order of the field.
+func (f *Field) Modulus() *big.Int {
+	return new(big.Int).Set(f.modulus)
+}
+
+// Zero returns the additive identity.
+func (f *Field) Zero() *Element {
+	return &Element{field: f, value: new(big.Int)}
+}
+
+// One returns the multiplicative identity.
+func (f *Field) One() *Element {
+	return &Element{field: f, value: big.NewInt(1)}
+}
+
+// NewElement returns x reduced modulo the order of the field.
+func (f *Field) NewElement(x *big.Int) *Element {
+	return f.Zero().SetBig(x)
+}
+
+// NewInt64 returns x reduced modulo the order of the field, so negative values
+// map to their additive inverse, e.g. -7 is p - 7.
+func (f *Field) NewInt64(x int64) *Element {
+	return f.Zero().SetInt64(x)
+}
+
+// Rand samples a uniformly random element.
+func (f *Field) Rand(r io.Reader) (*Element, error) {
+
+	var err error
+
+	var x *big.Int
+	if x, err = rand.Int(r, f.modulus); err != nil {
+		return nil, err
+	}
+
+	return &Element{field: f, value: x}, nil
+}
+
+// Equal reports whether two fields have the same order.
+func (f *Field) Equal(g *Field) bool {
+	return f == g || f.modulus.Cmp(g.modulus) == 0
+}
+
+// Element is a value modulo the order of its field. The operations follow the
+// math/big conventions: the receiver is set to the result and returned, so
+// results can be chained and allocations reused, and the arguments are never
+// modified. Unlike big.Int the value is always kept reduced.
+type Element struct {
+	field *Field
+	value *big.Int
+}
+
+// Field returns the field the element belongs to.
+func (z *Element) Field() *Field {
+	return z.field
+}
+
+// Big returns a copy of the reduced value.
+func (z *Element) Big() *big.Int {
+	return new(big.Int).Set(z.value)
+}
+
+// Set sets z to x and returns z.
+func (z *Element) Set(x *Element) *Element {
+
+	z.init(x.field)
+	z.value.Set(x.value)
+
+	return z
+}
+
+// SetBig sets z to x modulo the order of the field of z and returns z.
+func (z *Element) SetBig(x *big.Int) *Element {
+
+	z.init(z.field)
+	z.value.Mod(x, z.field.modulus)
+
+	return z
+}
+
+// SetInt64 sets z to x modulo the order of the field of z and returns z.
+func (z *Element) SetInt64(x int64) *Element {
+	return z.SetBig(big.NewInt(x))
+}
+
+// Add sets z to x + y and returns z.
+func (z *Element) Add(x, y *Element) *Element {
+
+	z.init(check(x, y))
+	z.value.Add(x.value, y.value)
+	z.value.Mod(z.value, z.field.modulus)
+
+	return z
+}
+
+// Sub sets z to x - y and returns z.
+func (z *Element) Sub(x, y *Element) *Element {
+
+	z.init(check(x, y))
+	z.value.Sub(x.value, y.value)
+	z.value.Mod(z.value, z.field.modulus)
+
+	return z
+}
+
+// Neg sets z to -x and returns z.
+func (z *Element) Neg(x *Element) *Element {
+
+	z.init(x.field)
+	z.value.Neg(x.value)
+	z.value.Mod(z.value, z.field.modulus)
+
+	return z
+}
+
+// Mul sets z to x * y and returns z.
+func (z *Element) Mul(x, y *Element) *Element {
+
+	z.init(check(x, y))
+	z.value.Mul(x.value, y.value)
+	z.value.Mod(z.value, z.field.modulus)
+
+	return z
+}
+
+// Inv sets z to the multiplicative inverse of x and returns z. If x is zero,
+// z is unchanged and Inv returns nil, as big.Int.ModInverse does.
+func (z *Element) Inv(x *Element) *Element {
+
+	if x.value.Sign() == 0 {
+		return nil
+	}
+
+	var inverse = new(big.Int).ModInverse(x.value, x.field.modulus)
+	if inverse == nil {
+		return nil
+	}
+
+	z.init(x.field)
+	z.value.Set(inverse)
+
+	return z
+}
+
+// Exp sets z to x**e and returns z. Negative exponents use the inverse of x.
+func (z *Element) Exp(x *Element, e *big.Int) *Element {
+
+	var base = x.value
+
+	if e.Sign() < 0 {
+
+		if base = new(big.Int).ModInverse(x.value, x.field.modulus); base == nil {
+			return nil
+		}
+
+		e = new(big.Int).Neg(e)
+	}
+
+	z.init(x.field)
+	z.value.Exp(base, e, z.field.modulus)
+
+	return z
+}
+
+// Sqrt sets z to a square root of x and returns z. If x is not a quadratic
+// residue, z is unchanged and Sqrt returns nil.
+func (z *Element) Sqrt(x *Element) *Element {
+
+	var root = new(big.Int).ModSqrt(x.value, x.field.modulus)
+	if root == nil {
+		return nil
+	}
+
+	z.init(x.field)
+	z.value.Set(root)
+
+	return z
+}
+
+// Legendre returns 1 if z is a non-zero quadratic residue, -1 if it is a
+// non-residue, and 0 if it is zero.
+func (z *Element) Legendre() int {
+	return big.Jacobi(z.value, z.field.modulus)
+}
+
+// Equal reports whether z and x are the same element of the same field.
+func (z *Element) Equal(x *Element) bool {
+	return z.field.Equal(x.field) && z.value.Cmp(x.value) == 0
+}
+
+// IsZero reports whether z is the additive identity.
+func (z *Element) IsZero() bool {
+	return z.value.Sign() == 0
+}
+
+// IsOne reports whether z is the multiplicative identity.
+func (z *Element) IsOne() bool {
+	return z.value.Cmp(big.NewInt(1)) == 0
+}
+
+func (z *Element) String() string {
+	return z.value.String()
+}
+
+// Format implements fmt.Formatter so elements print like big.Int values.
+func (z *Element) Format(s fmt.State, ch rune) {
+	z.value.Format(s, ch)
+}
+
+// init binds the receiver to a field, which allows new(Element) to be used as
+// the receiver of any operation.
+func (z *Element) init(f *Field) {
+
+	if f == nil {
+		panic("field: element is not bound to a field")
+	}
+
+	z.field = f
+
+	if z.value == nil {
+		z.value = new(big.Int)
+	}
+}
+
+func check(x, y *Element) *Field {
+
+	if !x.field.Equal(y.field) {
+		panic("field: operands belong to different fields")
+	}
+
+	return x.field
+}
@@ -0,0 +1,89 @@
+package field
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestArithmetic(t *testing.T) {
+
+	var f = New(big.NewInt(23))
+
+	var cases = []struct {
+		name     string
+		actual   *Element
+		expected int64
+	}{
+		{"20 + 5", new(Element).Add(f.NewInt64(20), f.NewInt64(5)), 2},
+		{"3 - 7", new(Element).Sub(f.NewInt64(3), f.NewInt64(7)), 19},
+		{"-7", new(Element).Neg(f.NewInt64(7)), 16},
+		{"SetInt64(-7)", f.NewInt64(-7), 16},
+		{"6 * 4", new(Element).Mul(f.NewInt64(6), f.NewInt64(4)), 1},
+		{"4^-1", new(Element).Inv(f.NewInt64(4)), 6},
+		{"2^11", new(Element).Exp(f.NewInt64(2), big.NewInt(11)), 1},
+		{"2^-1", new(Element).Exp(f.NewInt64(2), big.NewInt(-1)), 12},
+	}
+
+	var c = cases[0]
+	for _, c = range cases {
+		if !c.actual.Equal(f.NewInt64(c.expected)) {
+			t.Errorf("%s = %d, expected %d", c.name, c.actual, c.expected)
+		}
+	}
+
+	if new(Element).Inv(f.Zero()) != nil {
+		t.Errorf("expected zero to have no inverse")
+	}
+}
+
+func TestSqrt(t *testing.T) {
+
+	var f = New(big.NewInt(23))
+
+	var root = new(Element).Sqrt(f.NewInt64(2))
+	if root == nil || !new(Element).Mul(root, root).Equal(f.NewInt64(2)) {
+		t.Errorf("expected 2 to have a square root modulo 23")
+	}
+
+	if f.NewInt64(2).Legendre() != 1 || f.NewInt64(5).Legendre() != -1 || f.Zero().Legendre() != 0 {
+		t.Errorf("unexpected Legendre symbols")
+	}
+
+	if new(Element).Sqrt(f.NewInt64(5)) != nil {
+		t.Errorf("expected 5 to be a non-residue modulo 23")
+	}
+}
+
+func TestOperandsUnchanged(t *testing.T) {
+
+	var err error
+
+	var f = New(big.NewInt(997))
+
+	var x, y *Element
+	if x, err = f.Rand(rand.Reader); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	if y, err = f.Rand(rand.Reader); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	var xCopy, yCopy = x.Big(), y.Big()
+
+	_ = new(Element).Mul(x, y)
+	_ = new(Element).Sub(x, y)
+
+	if x.Big().Cmp(xCopy) != 0 || y.Big().Cmp(yCopy) != 0 {
+		t.Errorf("operands were modified")
+	}
+
+	// The receiver may alias an operand.
+	var z = new(Element).Set(x)
+	z.Add(z, y)
+
+	if !z.Equal(new(Element).Add(x, y)) {
+		t.Errorf("aliased addition = %d, expected %d", z, new(Element).Add(x, y))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/cloudflare/bn256"
 	"github.com/eugenekadish/cryptopalooza/sm"
@@ -11,6 +12,18 @@ import (
 
 func main() {
 
+	var err error
+
+	if len(os.Args) > 1 && os.Args[1] == "compile" {
+
+		if err = compile(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	var order = bn256.Order
 
 	// var order = bn256.Order.Set(big.NewInt(11))
@@ -25,11 +38,15 @@ func main() {
 
 	fmt.Printf("  - Example 1 QAP         %t \n", qap.E1QAP(order))
 	fmt.Printf("  - Example 1 Strong QAP  %t \n", qap.E1SQAP(order))
+	fmt.Printf("  - Example 1 R1CS        %t \n", qap.E1R1CS(order))
 
 	fmt.Printf("  - Example 2 QAP         %t \n", qap.E2QAP(order))
+	fmt.Printf("  - Example 2 Strong QAP  %t \n", qap.E2SQAP(order))
 	fmt.Printf("  - Example 2 R1CS        %t \n", qap.E2R1CS(order))
+	fmt.Printf("  - Example 2 Optimized   %t \n", qap.E2OQAP(order))
 
 	fmt.Printf("  - Example 3 QAP         %t \n", qap.E3QAP(order))
+	fmt.Printf("  - Example 3 Strong QAP  %t \n", qap.E3SQAP(order))
 	fmt.Printf("  - Example 3 R1CS        %t \n", qap.E3R1CS(order))
 
 	fmt.Println()
@@ -3,11 +3,11 @@ package sm
 import (
 	"bytes"
 	"crypto/rand"
-	"crypto/sha256"
 	"fmt"
 	"math/big"
 
 	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -16,60 +16,51 @@ var HPrime = map[string]int{
 	"B": 3, "E": 17, "K": 31, "M": 53,
 }
 
-// E1ACCUM computes an RSA accumulator and evaluates one of the elements as a
-// check of membership.
+// E1ACCUM accumulates the members of HPrime with a safe-prime RSA
+// accumulator, checks the membership of one of them, and deletes another with
+// the trapdoor, after which the old witness no longer verifies.
 func E1ACCUM(order *big.Int) bool {
 
 	var err error
 
-	// The two smallest strong primes
-	var p, q = big.NewInt(11), big.NewInt(17)
+	var a *RSAAccumulator
+	if a, err = NewRSAAccumulator(rand.Reader, 512); err != nil {
+		fmt.Printf("parameter generation %v", err)
+		return false
+	}
 
-	var N = new(big.Int).Mul(p, q)
+	var name string
+	for _, name = range []string{"B", "E", "K", "M"} {
+		if err = a.Add([]byte(name)); err != nil {
+			fmt.Printf("accumulation %v", err)
+			return false
+		}
+	}
 
-	var x *big.Int
-	if x, err = rand.Int(rand.Reader, N); err != nil {
-		fmt.Printf("parameter generation %v", err)
+	var w *big.Int
+	if w, err = a.MembershipWitness([]byte("E")); err != nil {
+		fmt.Printf("witness generation %v", err)
+		return false
 	}
 
-	// Quadratic residue of order the RSA modulus N
-	var g = new(big.Int).Exp(x, big.NewInt(2), N)
+	if !a.VerifyMembership([]byte("E"), w) {
+		return false
+	}
 
-	var A17 = new(big.Int).Exp(
-		g,
-		new(big.Int).Mul(
-			new(big.Int).Mul(
-				big.NewInt(1),
-				big.NewInt(3),
-			),
-			new(big.Int).Mul(
-				big.NewInt(31),
-				big.NewInt(53),
-			),
-		),
-		N,
-	)
+	if err = a.Delete([]byte("K")); err != nil {
+		fmt.Printf("deletion %v", err)
+		return false
+	}
 
-	var left = new(big.Int).Exp(
-		A17, big.NewInt(17), N,
-	)
+	// The old witness of E still includes K, and needs to be updated.
+	var stale = a.VerifyMembership([]byte("E"), w)
 
-	var right = new(big.Int).Exp(
-		g,
-		new(big.Int).Mul(
-			new(big.Int).Mul(
-				big.NewInt(3),
-				big.NewInt(17),
-			),
-			new(big.Int).Mul(
-				big.NewInt(31),
-				big.NewInt(53),
-			),
-		),
-		N,
-	)
+	if w, err = a.MembershipWitness([]byte("E")); err != nil {
+		fmt.Printf("witness generation %v", err)
+		return false
+	}
 
-	return bytes.Equal(left.Bytes(), right.Bytes())
+	return !stale && a.VerifyMembership([]byte("E"), w)
 }
 
 // E2ACCUM computes a bilinear-map accumulator and evaluates one of the elements
@@ -78,8 +69,10 @@ func E2ACCUM(order *big.Int) bool {
 
 	var err error
 
-	var s *big.Int
-	if s, err = rand.Int(rand.Reader, order); err != nil {
+	var f = field.New(order)
+
+	var s *field.Element
+	if s, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("parameter generation %v", err)
 	}
 
@@ -95,22 +88,22 @@ func E2ACCUM(order *big.Int) bool {
 
 	var A17 = new(bn256.G2).ScalarMult(
 		g2,
-		new(big.Int).Mul(
-			new(big.Int).Mul(
-				new(big.Int).Add(s, big.NewInt(3)),
-				new(big.Int).Add(big.NewInt(0), big.NewInt(1)),
+		new(field.Element).Mul(
+			new(field.Element).Mul(
+				new(field.Element).Add(s, f.NewInt64(3)),
+				f.One(),
 			),
-			new(big.Int).Mul(
-				new(big.Int).Add(s, big.NewInt(31)),
-				new(big.Int).Add(s, big.NewInt(53)),
+			new(field.Element).Mul(
+				new(field.Element).Add(s, f.NewInt64(31)),
+				new(field.Element).Add(s, f.NewInt64(53)),
 			),
-		),
+		).Big(),
 	)
 
 	var left = bn256.Pair(
 		new(bn256.G1).ScalarMult(
 			g1,
-			new(big.Int).Add(s, big.NewInt(17)),
+			new(field.Element).Add(s, f.NewInt64(17)).Big(),
 		),
 		A17,
 	)
@@ -119,16 +112,16 @@ func E2ACCUM(order *big.Int) bool {
 		g1,
 		new(bn256.G2).ScalarMult(
 			g2,
-			new(big.Int).Mul(
-				new(big.Int).Mul(
-					new(big.Int).Add(s, big.NewInt(3)),
-					new(big.Int).Add(s, big.NewInt(17)),
+			new(field.Element).Mul(
+				new(field.Element).Mul(
+					new(field.Element).Add(s, f.NewInt64(3)),
+					new(field.Element).Add(s, f.NewInt64(17)),
 				),
-				new(big.Int).Mul(
-					new(big.Int).Add(s, big.NewInt(31)),
-					new(big.Int).Add(s, big.NewInt(53)),
+				new(field.Element).Mul(
+					new(field.Element).Add(s, f.NewInt64(31)),
+					new(field.Element).Add(s, f.NewInt64(53)),
 				),
-			),
+			).Big(),
 		),
 	)
 
@@ -143,7 +136,7 @@ func E3ACCUM(order *big.Int) bool {
 
 	var e = []*struct {
 		val   *big.Int
-		nonce int
+		nonce uint32
 	}{
 		{val: big.NewInt(66)},
 		{val: big.NewInt(69)},
@@ -151,117 +144,46 @@ func E3ACCUM(order *big.Int) bool {
 		{val: big.NewInt(77)},
 	}
 
-	// var r [4]*big.Int
-	var r = make([]*big.Int, len(e))
+	var a *RSAAccumulator
+	if a, err = NewRSAAccumulator(rand.Reader, 512); err != nil {
+		fmt.Printf("parameter generation %v", err)
+		return false
+	}
 
-	var index int
 	var elem *struct {
 		val   *big.Int
-		nonce int
+		nonce uint32
 	}
 
-	for index, elem = range e {
-
-		var ct int
-
-		var h = sha256.New()
-		_, _ = h.Write(elem.val.Bytes())
-
-		var v = new(big.Int)
-		_ = v.SetBytes(h.Sum(nil))
+	for _, elem = range e {
 
-		ct++
+		_, elem.nonce = HashToPrime(elem.val.Bytes(), PrimeBits)
 
-		for !v.ProbablyPrime(0) {
-
-			h.Write(v.Bytes())
-			v.SetBytes(h.Sum(nil))
-
-			ct++
+		if err = a.Add(elem.val.Bytes()); err != nil {
+			fmt.Printf("accumulation %v", err)
+			return false
 		}
-
-		elem.nonce = ct
 	}
 
-	var p *big.Int
-	if p, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
-
-	var q *big.Int
-	if q, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
+	// The prime of 69 is recomputed from the nonce kept with it, which also
+	// checks it, and raises the witness to the value of the accumulator.
+	var r17, ok = VerifyHashToPrime(e[1].val.Bytes(), PrimeBits, e[1].nonce)
+	if !ok {
+		fmt.Printf("hash to prime of %v with nonce %d", e[1].val, e[1].nonce)
+		return false
 	}
 
-	var N = new(big.Int).Mul(p, q)
-
-	var x *big.Int
-	if x, err = rand.Int(rand.Reader, N); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
-
-	// Quadratic residue of order the RSA modulus N
-	var g = new(big.Int).Exp(x, big.NewInt(2), N)
-
-	for index, elem = range e {
-
-		var ct int
-
-		var v = elem.val
-		var n = elem.nonce
-
-		var h = sha256.New()
-
-		h.Write(v.Bytes())
-		v.SetBytes(h.Sum(nil))
-
-		ct++
-
-		for ct < n {
-			h.Write(v.Bytes())
-			v.SetBytes(h.Sum(nil))
-
-			ct++
-		}
-
-		r[index] = v
+	var A17 *big.Int
+	if A17, err = a.MembershipWitness(e[1].val.Bytes()); err != nil {
+		fmt.Printf("witness generation %v", err)
+		return false
 	}
 
-	var A17 = new(big.Int).Exp(
-		g,
-		new(big.Int).Mul(
-			new(big.Int).Mul(
-				big.NewInt(1),
-				r[0],
-			),
-			new(big.Int).Mul(
-				r[2],
-				r[3],
-			),
-		),
-		N,
-	)
-
 	var left = new(big.Int).Exp(
-		A17, r[1], N,
+		A17, r17, a.N,
 	)
 
-	var right = new(big.Int).Exp(
-		g,
-		new(big.Int).Mul(
-			new(big.Int).Mul(
-				r[0],
-				r[1],
-			),
-			new(big.Int).Mul(
-				r[2],
-				r[3],
-			),
-		),
-		N,
-	)
-
-	return bytes.Equal(left.Bytes(), right.Bytes())
+	return bytes.Equal(left.Bytes(), a.Value.Bytes())
 }
 
 // E4ACCUM computes an RSA accumulator and dynamically calculates a subset for
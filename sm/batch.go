@@ -0,0 +1,330 @@
+package sm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// ErrDuplicate is returned when a batch lists an element more than once.
+var ErrDuplicate = errors.New("batch has duplicate elements")
+
+// PoE is the proof of exponentiation of Wesolowski (Efficient Verifiable
+// Delay Functions) that u^x = w modulo N. For the prime challenge l derived
+// from the statement, it is Q = u^(x / l), and the verifier checks
+// Q^l * u^(x mod l) = w with two exponents of the size of l however large x
+// is.
+type PoE struct {
+	Q *big.Int
+}
+
+// PoKE is the proof of knowledge of exponent of Boneh, Bünz and Fisch
+// (Batching Techniques for Accumulators with Applications to IOPs and
+// Stateless Blockchains, PoKE2) that the prover knows x with u^x = w modulo
+// N. It commits to z = g^x for a generator g, and for the prime challenge l
+// and the challenge alpha it is Q = (u * g^alpha)^(x / l) and r = x mod l,
+// checked with Q^l * (u * g^alpha)^r = w * z^alpha. The exponent x is not
+// revealed, and is not needed to verify.
+type PoKE struct {
+	Z *big.Int
+	Q *big.Int
+	R *big.Int
+}
+
+// ProvePoE proves that u^x = w modulo n for a non-negative x.
+func ProvePoE(n, u, x, w *big.Int) *PoE {
+
+	var l = hashToPrime(transcript("PoE", n, u, x, w))
+
+	return &PoE{Q: new(big.Int).Exp(u, new(big.Int).Div(x, l), n)}
+}
+
+// VerifyPoE checks the proof that u^x = w modulo n.
+func VerifyPoE(n, u, x, w *big.Int, proof *PoE) bool {
+
+	if proof == nil || !residue(n, proof.Q) || x.Sign() < 0 {
+		return false
+	}
+
+	var l = hashToPrime(transcript("PoE", n, u, x, w))
+
+	var left = new(big.Int).Exp(proof.Q, l, n)
+	left.Mul(left, new(big.Int).Exp(u, new(big.Int).Mod(x, l), n))
+	left.Mod(left, n)
+
+	return left.Cmp(new(big.Int).Mod(w, n)) == 0
+}
+
+// ProvePoKE proves knowledge of a non-negative x with u^x = w modulo n, where
+// g generates a group of unknown order such as the quadratic residues of an
+// RSA accumulator.
+func ProvePoKE(n, g, u, x, w *big.Int) *PoKE {
+
+	var z = new(big.Int).Exp(g, x, n)
+
+	var l, _, base = challenges(n, g, u, w, z)
+
+	var q, r = new(big.Int).DivMod(x, l, new(big.Int))
+
+	return &PoKE{Z: z, Q: new(big.Int).Exp(base, q, n), R: r}
+}
+
+// VerifyPoKE checks the proof of knowledge of an x with u^x = w modulo n.
+func VerifyPoKE(n, g, u, w *big.Int, proof *PoKE) bool {
+
+	if proof == nil || !residue(n, proof.Z) || !residue(n, proof.Q) || proof.R == nil {
+		return false
+	}
+
+	var l, alpha, base = challenges(n, g, u, w, proof.Z)
+
+	if proof.R.Sign() < 0 || proof.R.Cmp(l) >= 0 {
+		return false
+	}
+
+	var left = new(big.Int).Exp(proof.Q, l, n)
+	left.Mul(left, new(big.Int).Exp(base, proof.R, n))
+	left.Mod(left, n)
+
+	var right = new(big.Int).Exp(proof.Z, alpha, n)
+	right.Mul(right, w)
+	right.Mod(right, n)
+
+	return left.Cmp(right) == 0
+}
+
+// challenges returns the prime challenge l and the challenge alpha of a PoKE,
+// and the base u * g^alpha.
+func challenges(n, g, u, w, z *big.Int) (*big.Int, *big.Int, *big.Int) {
+
+	var l = hashToPrime(transcript("PoKE", n, g, u, w, z))
+	var alpha = new(big.Int).SetBytes(transcript("PoKE alpha", n, g, u, w, z, l))
+
+	var base = new(big.Int).Exp(g, alpha, n)
+	base.Mul(base, u)
+	base.Mod(base, n)
+
+	return l, alpha, base
+}
+
+// transcript hashes the label and the values for a Fiat-Shamir challenge,
+// each value prefixed with its length so different statements do not
+// collide.
+func transcript(label string, values ...*big.Int) []byte {
+
+	var h = sha256.New()
+	h.Write([]byte(label))
+
+	var length [4]byte
+
+	var v *big.Int
+	for _, v = range values {
+
+		var b = v.Bytes()
+
+		binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+
+		h.Write(length[:])
+		h.Write(b)
+	}
+
+	return h.Sum(nil)
+}
+
+// residue reports whether x is a residue in [1, n).
+func residue(n, x *big.Int) bool {
+	return x != nil && x.Sign() > 0 && x.Cmp(n) < 0
+}
+
+// BatchMembershipWitness proves that all the elements of a batch are members
+// with a single witness W, which gives the value of the accumulator when
+// raised to the product of their representatives, and a PoE of it.
+type BatchMembershipWitness struct {
+	W     *big.Int
+	Proof *PoE
+}
+
+// BatchNonMembershipWitness proves that none of the elements of a batch is a
+// member. For the product y of their representatives and the exponent x of
+// the value, the Bezout coefficients of a*x + b*y = 1 give V = Value^a and
+// D = G^(-b) with V = D^y * G. The PoKE shows V is a power of the value, and
+// the PoE that D^y = V / G, so a and y need not be exponentiated by the
+// verifier.
+type BatchNonMembershipWitness struct {
+	V *big.Int
+	D *big.Int
+
+	Knowledge *PoKE
+	Proof     *PoE
+}
+
+// BatchMembershipWitness returns a witness for all the elements, which must
+// be distinct members.
+func (a *RSAAccumulator) BatchMembershipWitness(elements [][]byte) (*BatchMembershipWitness, error) {
+
+	var err error
+
+	var batch map[int]bool
+	if batch, err = a.batch(elements); err != nil {
+		return nil, err
+	}
+
+	if len(batch) != len(elements) {
+		return nil, ErrNotMember
+	}
+
+	var x, rest = big.NewInt(1), big.NewInt(1)
+
+	var i int
+	for i = range a.members {
+
+		if batch[i] {
+			x.Mul(x, a.members[i].prime)
+			continue
+		}
+
+		rest.Mul(rest, a.members[i].prime)
+	}
+
+	var w = new(big.Int).Exp(a.G, rest, a.N)
+
+	return &BatchMembershipWitness{W: w, Proof: ProvePoE(a.N, w, x, a.Value)}, nil
+}
+
+// VerifyBatchMembership checks that W raised to the product of the
+// representatives of the elements is the value of the accumulator.
+func (a *RSAAccumulator) VerifyBatchMembership(elements [][]byte, witness *BatchMembershipWitness) bool {
+
+	if witness == nil || !residue(a.N, witness.W) || !distinct(elements) {
+		return false
+	}
+
+	return VerifyPoE(a.N, witness.W, product(elements), a.Value, witness.Proof)
+}
+
+// BatchNonMembershipWitness returns a witness that none of the elements,
+// which must be distinct, is a member.
+func (a *RSAAccumulator) BatchNonMembershipWitness(elements [][]byte) (*BatchNonMembershipWitness, error) {
+
+	var err error
+
+	var batch map[int]bool
+	if batch, err = a.batch(elements); err != nil {
+		return nil, err
+	}
+
+	if len(batch) > 0 {
+		return nil, ErrMember
+	}
+
+	var y = product(elements)
+
+	var x = big.NewInt(1)
+
+	var i int
+	for i = range a.members {
+		x.Mul(x, a.members[i].prime)
+	}
+
+	var coefficient = new(big.Int).ModInverse(new(big.Int).Mod(x, y), y)
+	if coefficient == nil {
+		return nil, ErrMember
+	}
+
+	var exponent = new(big.Int).Mul(coefficient, x)
+	exponent.Sub(exponent, big.NewInt(1))
+	exponent.Div(exponent, y)
+
+	var v = new(big.Int).Exp(a.Value, coefficient, a.N)
+	var d = new(big.Int).Exp(a.G, exponent, a.N)
+
+	return &BatchNonMembershipWitness{
+		V:         v,
+		D:         d,
+		Knowledge: ProvePoKE(a.N, a.G, a.Value, coefficient, v),
+		Proof:     ProvePoE(a.N, d, y, a.quotient(v)),
+	}, nil
+}
+
+// VerifyBatchNonMembership checks the PoKE that V is a power of the value and
+// the PoE that D raised to the product of the representatives is V / G.
+func (a *RSAAccumulator) VerifyBatchNonMembership(elements [][]byte, witness *BatchNonMembershipWitness) bool {
+
+	if witness == nil || !residue(a.N, witness.V) || !residue(a.N, witness.D) || !distinct(elements) {
+		return false
+	}
+
+	if !VerifyPoKE(a.N, a.G, a.Value, witness.V, witness.Knowledge) {
+		return false
+	}
+
+	var quotient = a.quotient(witness.V)
+
+	return quotient != nil && VerifyPoE(a.N, witness.D, product(elements), quotient, witness.Proof)
+}
+
+// batch returns the indices of the members among the elements, which must be
+// distinct.
+func (a *RSAAccumulator) batch(elements [][]byte) (map[int]bool, error) {
+
+	if !distinct(elements) {
+		return nil, ErrDuplicate
+	}
+
+	var indices = make(map[int]bool)
+
+	var element []byte
+	for _, element = range elements {
+
+		var i = a.find(element)
+		if i >= 0 {
+			indices[i] = true
+		}
+	}
+
+	return indices, nil
+}
+
+// quotient returns v / G modulo N.
+func (a *RSAAccumulator) quotient(v *big.Int) *big.Int {
+
+	var inverse = new(big.Int).ModInverse(a.G, a.N)
+	if inverse == nil {
+		return nil
+	}
+
+	inverse.Mul(inverse, v)
+
+	return inverse.Mod(inverse, a.N)
+}
+
+// product returns the product of the representatives of the elements.
+func product(elements [][]byte) *big.Int {
+
+	var x = big.NewInt(1)
+
+	var element []byte
+	for _, element = range elements {
+		x.Mul(x, hashToPrime(element))
+	}
+
+	return x
+}
+
+func distinct(elements [][]byte) bool {
+
+	var seen = make(map[string]bool)
+
+	var element []byte
+	for _, element = range elements {
+
+		if seen[string(element)] {
+			return false
+		}
+
+		seen[string(element)] = true
+	}
+
+	return true
+}
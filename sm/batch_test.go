@@ -0,0 +1,127 @@
+package sm
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPoE(t *testing.T) {
+
+	var a = accumulate(t)
+
+	var x = new(big.Int).Lsh(big.NewInt(12345), 1000)
+	var w = new(big.Int).Exp(a.G, x, a.N)
+
+	var proof = ProvePoE(a.N, a.G, x, w)
+
+	if !VerifyPoE(a.N, a.G, x, w, proof) {
+		t.Fatal("expected the proof to verify")
+	}
+
+	if VerifyPoE(a.N, a.G, new(big.Int).Add(x, big.NewInt(1)), w, proof) {
+		t.Error("expected the proof not to verify for another exponent")
+	}
+
+	if VerifyPoE(a.N, a.G, x, new(big.Int).Exp(w, big.NewInt(2), a.N), proof) {
+		t.Error("expected the proof not to verify for another power")
+	}
+}
+
+func TestPoKE(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E")
+
+	var x *big.Int
+	if x, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 1024)); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	var w = new(big.Int).Exp(a.Value, x, a.N)
+
+	var proof = ProvePoKE(a.N, a.G, a.Value, x, w)
+
+	if !VerifyPoKE(a.N, a.G, a.Value, w, proof) {
+		t.Fatal("expected the proof to verify")
+	}
+
+	if VerifyPoKE(a.N, a.G, a.Value, new(big.Int).Exp(w, big.NewInt(2), a.N), proof) {
+		t.Error("expected the proof not to verify for another power")
+	}
+
+	var forged = &PoKE{Z: proof.Z, Q: proof.Q, R: new(big.Int).Add(proof.R, big.NewInt(1))}
+
+	if VerifyPoKE(a.N, a.G, a.Value, w, forged) {
+		t.Error("expected a forged remainder not to verify")
+	}
+}
+
+func TestBatchMembership(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M", "P")
+
+	var batch = [][]byte{[]byte("E"), []byte("M"), []byte("B")}
+
+	var w *BatchMembershipWitness
+	if w, err = a.BatchMembershipWitness(batch); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if !a.VerifyBatchMembership(batch, w) {
+		t.Fatal("expected the batch witness to verify")
+	}
+
+	if a.VerifyBatchMembership(batch[:2], w) {
+		t.Error("expected the witness not to verify for part of the batch")
+	}
+
+	if a.VerifyBatchMembership(append(batch, []byte("K")), w) {
+		t.Error("expected the witness not to verify for a larger batch")
+	}
+
+	if _, err = a.BatchMembershipWitness([][]byte{[]byte("E"), []byte("Z")}); err != ErrNotMember {
+		t.Errorf("batch with a non-member: %v, expected %v", err, ErrNotMember)
+	}
+
+	if _, err = a.BatchMembershipWitness([][]byte{[]byte("E"), []byte("E")}); err != ErrDuplicate {
+		t.Errorf("batch with duplicates: %v, expected %v", err, ErrDuplicate)
+	}
+}
+
+func TestBatchNonMembership(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M")
+
+	var batch = [][]byte{[]byte("A"), []byte("C"), []byte("Z")}
+
+	var w *BatchNonMembershipWitness
+	if w, err = a.BatchNonMembershipWitness(batch); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if !a.VerifyBatchNonMembership(batch, w) {
+		t.Fatal("expected the batch witness to verify")
+	}
+
+	if a.VerifyBatchNonMembership(append(batch[:2:2], []byte("E")), w) {
+		t.Error("expected the witness not to verify with a member")
+	}
+
+	if _, err = a.BatchNonMembershipWitness([][]byte{[]byte("A"), []byte("K")}); err != ErrMember {
+		t.Errorf("batch with a member: %v, expected %v", err, ErrMember)
+	}
+
+	if err = a.Add([]byte("C")); err != nil {
+		t.Fatalf("adding C failed: %v", err)
+	}
+
+	if a.VerifyBatchNonMembership(batch, w) {
+		t.Error("expected the witness not to verify once C is added")
+	}
+}
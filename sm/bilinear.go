@@ -0,0 +1,185 @@
+package sm
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// BilinearAccumulator accumulates a set of elements of the scalar field of
+// bn256 as
+//
+//	Value = G2^((s + x_1) * ... * (s + x_n))
+//
+// where s is the trapdoor of the accumulator, following Nguyen (Accumulators
+// from Bilinear Pairings and Applications). A witness of x is
+// W = Value^(1 / (s + x)), which is checked with e(S * G1^x, W) = e(G1, Value)
+// for the public S = G1^s. Adding and deleting elements, and computing witnesses from
+// scratch, need the trapdoor.
+type BilinearAccumulator struct {
+	Field *field.Field
+
+	G1    *bn256.G1
+	G2    *bn256.G2
+	S     *bn256.G1
+	Value *bn256.G2
+
+	// s is the trapdoor, or nil if it is not known.
+	s *field.Element
+
+	members []*field.Element
+}
+
+// NewBilinearAccumulator samples the trapdoor and the generators, and returns
+// the accumulator of the empty set, whose value is G2.
+func NewBilinearAccumulator(random io.Reader) (*BilinearAccumulator, error) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var s *field.Element
+	if s, err = f.Rand(random); err != nil {
+		return nil, err
+	}
+
+	var g1 *bn256.G1
+	if _, g1, err = bn256.RandomG1(random); err != nil {
+		return nil, err
+	}
+
+	var g2 *bn256.G2
+	if _, g2, err = bn256.RandomG2(random); err != nil {
+		return nil, err
+	}
+
+	return &BilinearAccumulator{
+		Field: f,
+		G1:    g1,
+		G2:    g2,
+		S:     new(bn256.G1).ScalarMult(g1, s.Big()),
+		Value: new(bn256.G2).Set(g2),
+		s:     s,
+	}, nil
+}
+
+// Members returns the accumulated elements in the order they were added.
+func (a *BilinearAccumulator) Members() []*field.Element {
+	return append([]*field.Element(nil), a.members...)
+}
+
+// Add accumulates x by raising the value to s + x.
+func (a *BilinearAccumulator) Add(x *field.Element) error {
+
+	if a.s == nil {
+		return ErrTrapdoor
+	}
+
+	if a.find(x) >= 0 {
+		return ErrMember
+	}
+
+	a.Value = new(bn256.G2).ScalarMult(a.Value, new(field.Element).Add(a.s, x).Big())
+	a.members = append(a.members, x)
+
+	return nil
+}
+
+// Delete removes x by raising the value to 1 / (s + x).
+func (a *BilinearAccumulator) Delete(x *field.Element) error {
+
+	if a.s == nil {
+		return ErrTrapdoor
+	}
+
+	var i = a.find(x)
+	if i < 0 {
+		return ErrNotMember
+	}
+
+	a.Value = a.root(x)
+	a.members = append(a.members[:i:i], a.members[i+1:]...)
+
+	return nil
+}
+
+// MembershipWitness returns Value^(1 / (s + x)).
+func (a *BilinearAccumulator) MembershipWitness(x *field.Element) (*bn256.G2, error) {
+
+	if a.s == nil {
+		return nil, ErrTrapdoor
+	}
+
+	if a.find(x) < 0 {
+		return nil, ErrNotMember
+	}
+
+	return a.root(x), nil
+}
+
+// VerifyMembership checks that e(S * G1^x, W) = e(G1, Value).
+func (a *BilinearAccumulator) VerifyMembership(x *field.Element, witness *bn256.G2) bool {
+
+	if witness == nil {
+		return false
+	}
+
+	var left = bn256.Pair(new(bn256.G1).Add(a.S, new(bn256.G1).ScalarMult(a.G1, x.Big())), witness)
+	var right = bn256.Pair(a.G1, a.Value)
+
+	return bytes.Equal(left.Marshal(), right.Marshal())
+}
+
+// UpdateOnAdd returns the witness of x once y is added, given the value of
+// the accumulator before the addition. The new witness is
+// previous^((s + y) / (s + x)) = previous * W^(y - x), so it does not need the
+// trapdoor, but the additions must be applied one at a time.
+func (a *BilinearAccumulator) UpdateOnAdd(x *field.Element, witness, previous *bn256.G2, y *field.Element) *bn256.G2 {
+
+	var w = new(bn256.G2).ScalarMult(witness, new(field.Element).Sub(y, x).Big())
+
+	return w.Add(w, previous)
+}
+
+// UpdateOnDelete returns the witness of x once y is deleted, given the value
+// of the accumulator after the deletion. Since
+//
+//	1 / ((s + x)(s + y)) = (1 / (s + x) - 1 / (s + y)) / (y - x)
+//
+// the new witness is (W / Value)^(1 / (y - x)), which does not need the
+// trapdoor. It returns ErrNotMember if x itself is deleted.
+func (a *BilinearAccumulator) UpdateOnDelete(x *field.Element, witness *bn256.G2, y *field.Element) (*bn256.G2, error) {
+
+	var inverse = new(field.Element).Inv(new(field.Element).Sub(y, x))
+	if inverse == nil {
+		return nil, ErrNotMember
+	}
+
+	var w = new(bn256.G2).Add(witness, new(bn256.G2).Neg(a.Value))
+
+	return w.ScalarMult(w, inverse.Big()), nil
+}
+
+// find returns the index of x in the members, or -1.
+func (a *BilinearAccumulator) find(x *field.Element) int {
+
+	var i int
+	for i = range a.members {
+		if a.members[i].Equal(x) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// root returns Value^(1 / (s + x)). The trapdoor is random, so s + x is not
+// zero but with negligible probability.
+func (a *BilinearAccumulator) root(x *field.Element) *bn256.G2 {
+
+	var inverse = new(field.Element).Inv(new(field.Element).Add(a.s, x))
+
+	return new(bn256.G2).ScalarMult(a.Value, inverse.Big())
+}
@@ -0,0 +1,135 @@
+package sm
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestBilinearAccumulator(t *testing.T) {
+
+	var err error
+
+	var a *BilinearAccumulator
+	if a, err = NewBilinearAccumulator(rand.Reader); err != nil {
+		t.Fatalf("generating the accumulator failed: %v", err)
+	}
+
+	var f = a.Field
+
+	var x int64
+	for _, x = range []int64{3, 17, 31, 53} {
+		if err = a.Add(f.NewInt64(x)); err != nil {
+			t.Fatalf("adding %d failed: %v", x, err)
+		}
+	}
+
+	var w *bn256.G2
+	if w, err = a.MembershipWitness(f.NewInt64(17)); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if !a.VerifyMembership(f.NewInt64(17), w) {
+		t.Error("expected the witness of 17 to verify")
+	}
+
+	if a.VerifyMembership(f.NewInt64(18), w) {
+		t.Error("expected the witness of 17 not to verify for 18")
+	}
+
+	if err = a.Add(f.NewInt64(17)); err != ErrMember {
+		t.Errorf("adding a member twice: %v, expected %v", err, ErrMember)
+	}
+
+	if err = a.Delete(f.NewInt64(17)); err != nil {
+		t.Fatalf("deletion failed: %v", err)
+	}
+
+	if a.VerifyMembership(f.NewInt64(17), w) {
+		t.Error("expected the witness of a deleted element not to verify")
+	}
+
+	if _, err = a.MembershipWitness(f.NewInt64(17)); err != ErrNotMember {
+		t.Errorf("witness of a deleted element: %v, expected %v", err, ErrNotMember)
+	}
+
+	if len(a.Members()) != 3 {
+		t.Errorf("members = %v, expected 3, 31 and 53", a.Members())
+	}
+
+	var public = &BilinearAccumulator{Field: f, G1: a.G1, G2: a.G2, S: a.S, Value: a.Value}
+
+	if err = public.Add(f.NewInt64(5)); err != ErrTrapdoor {
+		t.Errorf("adding without the trapdoor: %v, expected %v", err, ErrTrapdoor)
+	}
+}
+
+func TestBilinearAccumulatorUpdate(t *testing.T) {
+
+	var err error
+
+	var a *BilinearAccumulator
+	if a, err = NewBilinearAccumulator(rand.Reader); err != nil {
+		t.Fatalf("generating the accumulator failed: %v", err)
+	}
+
+	var f = a.Field
+
+	var x *field.Element
+	for _, x = range []*field.Element{f.NewInt64(3), f.NewInt64(17)} {
+		if err = a.Add(x); err != nil {
+			t.Fatalf("adding %s failed: %v", x, err)
+		}
+	}
+
+	var w *bn256.G2
+	if w, err = a.MembershipWitness(f.NewInt64(17)); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	// The holder of w only sees the changes and the values of the accumulator.
+	var changes = []struct {
+		element *field.Element
+		add     bool
+	}{
+		{f.NewInt64(31), true},
+		{f.NewInt64(3), false},
+		{f.NewInt64(53), true},
+		{f.NewInt64(31), false},
+	}
+
+	var i int
+	for i = range changes {
+
+		var previous = new(bn256.G2).Set(a.Value)
+
+		if changes[i].add {
+
+			if err = a.Add(changes[i].element); err != nil {
+				t.Fatalf("adding %s failed: %v", changes[i].element, err)
+			}
+
+			w = a.UpdateOnAdd(f.NewInt64(17), w, previous, changes[i].element)
+
+		} else {
+
+			if err = a.Delete(changes[i].element); err != nil {
+				t.Fatalf("deleting %s failed: %v", changes[i].element, err)
+			}
+
+			if w, err = a.UpdateOnDelete(f.NewInt64(17), w, changes[i].element); err != nil {
+				t.Fatalf("updating for %s failed: %v", changes[i].element, err)
+			}
+		}
+
+		if !a.VerifyMembership(f.NewInt64(17), w) {
+			t.Fatalf("expected the witness to verify after change %d", i)
+		}
+	}
+
+	if _, err = a.UpdateOnDelete(f.NewInt64(17), w, f.NewInt64(17)); err != ErrNotMember {
+		t.Errorf("updating for the deletion of the member: %v, expected %v", err, ErrNotMember)
+	}
+}
@@ -0,0 +1,107 @@
+package sm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// HashToPrimeDomain prefixes every hash of HashToPrime, so its digests differ
+// from those of any other use of SHA-256 on the same data.
+const HashToPrimeDomain = "cryptopalooza/sm/hash-to-prime/v1"
+
+// HashToPrime maps the data to a prime of exactly the given number of bits,
+// and returns it with its nonce. The candidate for the nonce i is built from
+// the blocks
+//
+//	SHA-256(HashToPrimeDomain || bits || i || j || data)
+//
+// for j = 0, 1, ..., where bits, i and j are 4-byte big-endian integers. The
+// first (bits + 7) / 8 bytes of the blocks are read as a big-endian integer,
+// reduced to its low bits bits, and its top and bottom bits are set. The nonce
+// is the first i whose candidate is prime, for the Miller-Rabin and
+// Baillie-PSW tests of big.Int.ProbablyPrime(20). It panics if bits is less
+// than 2.
+func HashToPrime(data []byte, bits int) (*big.Int, uint32) {
+
+	var nonce uint32
+	for nonce = 0; ; nonce++ {
+
+		var x = candidate(data, bits, nonce)
+
+		if x.ProbablyPrime(20) {
+			return x, nonce
+		}
+	}
+}
+
+// VerifyHashToPrime checks that the nonce is the one HashToPrime returns for
+// the data, and returns the prime. The candidates of the smaller nonces are
+// checked to be composite, so the prime of the data is unique. The size is
+// taken alongside the data and the nonce, rather than fixed, since it is
+// hashed into every candidate: the same data and nonce give unrelated
+// candidates for different sizes, so the verifier has to know which one the
+// prime was made for.
+func VerifyHashToPrime(data []byte, bits int, nonce uint32) (*big.Int, bool) {
+
+	var x = candidate(data, bits, nonce)
+
+	if !x.ProbablyPrime(20) {
+		return nil, false
+	}
+
+	var i uint32
+	for i = 0; i < nonce; i++ {
+		if candidate(data, bits, i).ProbablyPrime(20) {
+			return nil, false
+		}
+	}
+
+	return x, true
+}
+
+// candidate returns the odd integer of the given size for the nonce.
+func candidate(data []byte, bits int, nonce uint32) *big.Int {
+
+	if bits < 2 {
+		panic("sm: hash to prime needs at least 2 bits")
+	}
+
+	var n = (bits + 7) / 8
+
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(bits))
+	binary.BigEndian.PutUint32(header[4:], nonce)
+
+	var stream []byte
+
+	var block uint32
+	for block = 0; len(stream) < n; block++ {
+
+		binary.BigEndian.PutUint32(header[8:], block)
+
+		var h = sha256.New()
+		h.Write([]byte(HashToPrimeDomain))
+		h.Write(header[:])
+		h.Write(data)
+
+		stream = h.Sum(stream)
+	}
+
+	var x = new(big.Int).SetBytes(stream[:n])
+
+	x.Mod(x, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	x.SetBit(x, bits-1, 1)
+	x.SetBit(x, 0, 1)
+
+	return x
+}
+
+// hashToPrime returns the prime that represents the data in the accumulators
+// and the challenges of their proofs, of PrimeBits bits.
+func hashToPrime(data []byte) *big.Int {
+
+	var x, _ = HashToPrime(data, PrimeBits)
+
+	return x
+}
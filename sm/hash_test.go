@@ -0,0 +1,79 @@
+package sm
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestHashToPrime(t *testing.T) {
+
+	var bits int
+	for _, bits = range []int{2, 13, 64, 256, 1000} {
+
+		var x, nonce = HashToPrime([]byte("E"), bits)
+
+		if x.BitLen() != bits || !x.ProbablyPrime(20) {
+			t.Errorf("%d bits: %s is not a prime of %d bits", bits, x, bits)
+		}
+
+		var y, ok = VerifyHashToPrime([]byte("E"), bits, nonce)
+		if !ok || x.Cmp(y) != 0 {
+			t.Errorf("%d bits: expected nonce %d to give %s", bits, nonce, x)
+		}
+
+		if y, _ = HashToPrime([]byte("K"), bits); bits >= 64 && x.Cmp(y) == 0 {
+			t.Errorf("%d bits: expected E and K to have different primes", bits)
+		}
+	}
+
+	var x, _ = HashToPrime([]byte("E"), PrimeBits)
+	if x.Cmp(hashToPrime([]byte("E"))) != 0 {
+		t.Error("expected the representatives to be the primes of PrimeBits bits")
+	}
+
+	// The hashes are prefixed with the domain, so the first candidate is not
+	// the plain digest of the data.
+	var digest = sha256.Sum256([]byte("E"))
+
+	var plain = new(big.Int).SetBytes(digest[:])
+	plain.SetBit(plain, PrimeBits-1, 1)
+	plain.SetBit(plain, 0, 1)
+
+	if candidate([]byte("E"), PrimeBits, 0).Cmp(plain) == 0 {
+		t.Error("expected the candidates to be separated from plain SHA-256")
+	}
+}
+
+func TestVerifyHashToPrimeNonce(t *testing.T) {
+
+	// Search for data with a later prime, so the nonce of another prime can be
+	// checked to be rejected.
+	var data []byte
+	var x *big.Int
+	var nonce uint32
+
+	var i byte
+	for i = 0; nonce == 0; i++ {
+		data = []byte{i}
+		x, nonce = HashToPrime(data, 64)
+	}
+
+	var n uint32
+	for n = nonce + 1; !candidate(data, 64, n).ProbablyPrime(20); n++ {
+	}
+
+	var _, ok = VerifyHashToPrime(data, 64, n)
+	if ok {
+		t.Errorf("expected the later prime of nonce %d not to verify", n)
+	}
+
+	if _, ok = VerifyHashToPrime(data, 64, nonce-1); ok {
+		t.Errorf("expected the composite of nonce %d not to verify", nonce-1)
+	}
+
+	var y *big.Int
+	if y, ok = VerifyHashToPrime(data, 64, nonce); !ok || y.Cmp(x) != 0 {
+		t.Errorf("expected nonce %d to give %s", nonce, x)
+	}
+}
@@ -0,0 +1,341 @@
+package sm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// MinRSABits is the smallest modulus NewRSAAccumulator generates. Moduli this
+// small are only useful in tests, where 2048 bits or more are needed for any
+// security.
+const MinRSABits = 16
+
+// PrimeBits is the size of the primes that represent the elements in the
+// exponent of the RSA accumulator.
+const PrimeBits = 256
+
+// ErrModulusSize is returned for a modulus smaller than MinRSABits.
+var ErrModulusSize = errors.New("modulus is too small")
+
+// ErrMember is returned when adding an element that is already accumulated,
+// or proving that it is not.
+var ErrMember = errors.New("element is already a member")
+
+// ErrNotMember is returned when deleting, or witnessing, an element that is
+// not accumulated.
+var ErrNotMember = errors.New("element is not a member")
+
+// ErrTrapdoor is returned when deleting from an accumulator that does not
+// know the factorization of its modulus.
+var ErrTrapdoor = errors.New("accumulator has no trapdoor")
+
+// RSAAccumulator accumulates a set of elements as
+//
+//	Value = G^(x_1 * ... * x_n) mod N
+//
+// where x_i is the prime representative of the i-th element. N is the product
+// of the safe primes p = 2p' + 1 and q = 2q' + 1, and G generates the
+// quadratic residues, a group of order p'q' that is the trapdoor of the
+// accumulator. N, G and Value are public, and are all that is needed to verify
+// a witness.
+type RSAAccumulator struct {
+	N     *big.Int
+	G     *big.Int
+	Value *big.Int
+
+	// order is p'q', the order of G, or nil if the factorization of N is not
+	// known.
+	order *big.Int
+
+	members []member
+}
+
+// member is an accumulated element and its prime representative.
+type member struct {
+	element []byte
+	prime   *big.Int
+}
+
+// NewRSAAccumulator generates a modulus of the given size from two safe
+// primes, and a generator of its quadratic residues, and returns the
+// accumulator of the empty set, whose value is G.
+func NewRSAAccumulator(random io.Reader, bits int) (*RSAAccumulator, error) {
+
+	var err error
+
+	if bits < MinRSABits {
+		return nil, ErrModulusSize
+	}
+
+	var p, p1, q, q1 *big.Int
+
+	if p, p1, err = safePrime(random, bits/2); err != nil {
+		return nil, err
+	}
+
+	for q == nil || q.Cmp(p) == 0 {
+		if q, q1, err = safePrime(random, bits-bits/2); err != nil {
+			return nil, err
+		}
+	}
+
+	var n = new(big.Int).Mul(p, q)
+	var order = new(big.Int).Mul(p1, q1)
+
+	var one = big.NewInt(1)
+
+	// A random square generates the quadratic residues unless its order is
+	// only p', q' or 1, which the loop rules out.
+	var g *big.Int
+	for g == nil || new(big.Int).Exp(g, p1, n).Cmp(one) == 0 || new(big.Int).Exp(g, q1, n).Cmp(one) == 0 {
+
+		var x *big.Int
+		if x, err = rand.Int(random, n); err != nil {
+			return nil, err
+		}
+
+		g = new(big.Int).Exp(x, big.NewInt(2), n)
+	}
+
+	return &RSAAccumulator{N: n, G: g, Value: new(big.Int).Set(g), order: order}, nil
+}
+
+// Members returns the accumulated elements in the order they were added.
+func (a *RSAAccumulator) Members() [][]byte {
+
+	var elements = make([][]byte, len(a.members))
+
+	var i int
+	for i = range a.members {
+		elements[i] = a.members[i].element
+	}
+
+	return elements
+}
+
+// Add accumulates the element by raising the value to its representative.
+func (a *RSAAccumulator) Add(element []byte) error {
+
+	if a.find(element) >= 0 {
+		return ErrMember
+	}
+
+	var x = hashToPrime(element)
+
+	a.Value = new(big.Int).Exp(a.Value, x, a.N)
+	a.members = append(a.members, member{element: append([]byte(nil), element...), prime: x})
+
+	return nil
+}
+
+// Delete removes the element by raising the value to the inverse of its
+// representative modulo the order of G, which needs the trapdoor.
+func (a *RSAAccumulator) Delete(element []byte) error {
+
+	if a.order == nil {
+		return ErrTrapdoor
+	}
+
+	var i = a.find(element)
+	if i < 0 {
+		return ErrNotMember
+	}
+
+	var inverse = new(big.Int).ModInverse(a.members[i].prime, a.order)
+	if inverse == nil {
+		return ErrTrapdoor
+	}
+
+	a.Value = new(big.Int).Exp(a.Value, inverse, a.N)
+	a.members = append(a.members[:i:i], a.members[i+1:]...)
+
+	return nil
+}
+
+// MembershipWitness returns G raised to the representatives of all the other
+// members, which gives the value when raised to the representative of the
+// element. It only uses the set, not the trapdoor.
+func (a *RSAAccumulator) MembershipWitness(element []byte) (*big.Int, error) {
+
+	var i = a.find(element)
+	if i < 0 {
+		return nil, ErrNotMember
+	}
+
+	var exponent = big.NewInt(1)
+
+	var k int
+	for k = range a.members {
+		if k != i {
+			exponent.Mul(exponent, a.members[k].prime)
+		}
+	}
+
+	return new(big.Int).Exp(a.G, exponent, a.N), nil
+}
+
+// VerifyMembership checks that the witness raised to the representative of
+// the element is the value of the accumulator.
+func (a *RSAAccumulator) VerifyMembership(element []byte, witness *big.Int) bool {
+
+	if witness == nil || witness.Sign() <= 0 || witness.Cmp(a.N) >= 0 {
+		return false
+	}
+
+	return new(big.Int).Exp(witness, hashToPrime(element), a.N).Cmp(a.Value) == 0
+}
+
+// UpdateOnAdd returns the witness of a member once the elements are added,
+// which is the old witness raised to their representatives. It needs neither
+// the trapdoor nor the set.
+func (a *RSAAccumulator) UpdateOnAdd(witness *big.Int, added ...[]byte) *big.Int {
+
+	var w = new(big.Int).Set(witness)
+
+	var element []byte
+	for _, element = range added {
+		w.Exp(w, hashToPrime(element), a.N)
+	}
+
+	return w
+}
+
+// UpdateOnDelete returns the witness of a member once the elements are
+// deleted, given the value of the accumulator after the deletion. For the
+// representative x of the member and the product y of the deleted ones, the
+// Bezout coefficients of a*x + b*y = 1 give the new witness W^b * Value^a,
+// since its x-th power is Value^(b*y) * Value^(a*x). It needs neither the
+// trapdoor nor the set, and returns ErrNotMember if the member itself is
+// deleted.
+func (a *RSAAccumulator) UpdateOnDelete(element []byte, witness *big.Int, deleted ...[]byte) (*big.Int, error) {
+
+	var x = hashToPrime(element)
+
+	var y = big.NewInt(1)
+
+	var d []byte
+	for _, d = range deleted {
+		y.Mul(y, hashToPrime(d))
+	}
+
+	var s, t = new(big.Int), new(big.Int)
+	if new(big.Int).GCD(s, t, x, y).Cmp(big.NewInt(1)) != 0 {
+		return nil, ErrNotMember
+	}
+
+	var w = new(big.Int).Exp(witness, t, a.N)
+	if w == nil {
+		return nil, ErrNotMember
+	}
+
+	var v = new(big.Int).Exp(a.Value, s, a.N)
+	if v == nil {
+		return nil, ErrNotMember
+	}
+
+	w.Mul(w, v)
+	w.Mod(w, a.N)
+
+	return w, nil
+}
+
+// NonMembershipWitness proves that an element with representative u is not
+// accumulated in the value g^x, following Li, Li and Xue (Universal
+// Accumulators with Efficient Nonmembership Proofs). Since u is a prime that
+// does not divide x, there are Bezout coefficients with a*x + b*u = 1, and
+// the witness is a and D = G^(-b).
+type NonMembershipWitness struct {
+	A *big.Int
+	D *big.Int
+}
+
+// NonMembershipWitness computes the Bezout coefficients of the representative
+// of the element and the product of the representatives of the members, with
+// 0 <= a < u so that D = G^((a*x - 1) / u) has a non-negative exponent. Like
+// MembershipWitness it only uses the set, not the trapdoor.
+func (a *RSAAccumulator) NonMembershipWitness(element []byte) (*NonMembershipWitness, error) {
+
+	if a.find(element) >= 0 {
+		return nil, ErrMember
+	}
+
+	var u = hashToPrime(element)
+
+	var x = big.NewInt(1)
+
+	var i int
+	for i = range a.members {
+		x.Mul(x, a.members[i].prime)
+	}
+
+	// Two elements with the same representative can not be told apart.
+	var coefficient = new(big.Int).ModInverse(new(big.Int).Mod(x, u), u)
+	if coefficient == nil {
+		return nil, ErrMember
+	}
+
+	var exponent = new(big.Int).Mul(coefficient, x)
+	exponent.Sub(exponent, big.NewInt(1))
+	exponent.Div(exponent, u)
+
+	return &NonMembershipWitness{A: coefficient, D: new(big.Int).Exp(a.G, exponent, a.N)}, nil
+}
+
+// VerifyNonMembership checks that Value^a = D^u * G, which holds when
+// a*x + b*u = 1 for the exponent x of the value.
+func (a *RSAAccumulator) VerifyNonMembership(element []byte, witness *NonMembershipWitness) bool {
+
+	if witness == nil || witness.A == nil || witness.A.Sign() < 0 {
+		return false
+	}
+
+	if witness.D == nil || witness.D.Sign() <= 0 || witness.D.Cmp(a.N) >= 0 {
+		return false
+	}
+
+	var left = new(big.Int).Exp(a.Value, witness.A, a.N)
+
+	var right = new(big.Int).Exp(witness.D, hashToPrime(element), a.N)
+	right.Mul(right, a.G)
+	right.Mod(right, a.N)
+
+	return left.Cmp(right) == 0
+}
+
+// find returns the index of the element in the members, or -1.
+func (a *RSAAccumulator) find(element []byte) int {
+
+	var i int
+	for i = range a.members {
+		if bytes.Equal(a.members[i].element, element) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// safePrime returns a prime p = 2q + 1 of the given size, where q is prime
+// too.
+func safePrime(random io.Reader, bits int) (*big.Int, *big.Int, error) {
+
+	var err error
+
+	for {
+
+		var q *big.Int
+		if q, err = rand.Prime(random, bits-1); err != nil {
+			return nil, nil, err
+		}
+
+		var p = new(big.Int).Lsh(q, 1)
+		p.Add(p, big.NewInt(1))
+
+		if p.ProbablyPrime(20) {
+			return p, q, nil
+		}
+	}
+}
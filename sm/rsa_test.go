@@ -0,0 +1,268 @@
+package sm
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// accumulate returns an accumulator with a small modulus and the elements.
+func accumulate(t *testing.T, elements ...string) *RSAAccumulator {
+
+	var err error
+
+	var a *RSAAccumulator
+	if a, err = NewRSAAccumulator(rand.Reader, 128); err != nil {
+		t.Fatalf("generating the accumulator failed: %v", err)
+	}
+
+	var element string
+	for _, element = range elements {
+		if err = a.Add([]byte(element)); err != nil {
+			t.Fatalf("adding %s failed: %v", element, err)
+		}
+	}
+
+	return a
+}
+
+func TestRSAAccumulatorModulus(t *testing.T) {
+
+	var a = accumulate(t)
+
+	if a.N.BitLen() != 128 {
+		t.Errorf("modulus has %d bits, expected 128", a.N.BitLen())
+	}
+
+	if a.Value.Cmp(a.G) != 0 {
+		t.Errorf("value of the empty set is %s, expected G = %s", a.Value, a.G)
+	}
+
+	// The order of G is p'q', with N = (2p' + 1)(2q' + 1).
+	if new(big.Int).Exp(a.G, a.order, a.N).Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("G^order = %s, expected 1", new(big.Int).Exp(a.G, a.order, a.N))
+	}
+
+	// N - 1 - 4p'q' = 2(p' + q'), so p' and q' are the roots of
+	// z^2 - (p' + q')z + p'q'.
+	var sum = new(big.Int).Sub(a.N, big.NewInt(1))
+	sum.Sub(sum, new(big.Int).Lsh(a.order, 2))
+	sum.Rsh(sum, 1)
+
+	var discriminant = new(big.Int).Mul(sum, sum)
+	discriminant.Sub(discriminant, new(big.Int).Lsh(a.order, 2))
+
+	var root = new(big.Int).Sqrt(discriminant)
+
+	var factor *big.Int
+	for _, factor = range []*big.Int{new(big.Int).Add(sum, root), new(big.Int).Sub(sum, root)} {
+
+		factor.Rsh(factor, 1)
+
+		var p = new(big.Int).Lsh(factor, 1)
+		p.Add(p, big.NewInt(1))
+
+		if !factor.ProbablyPrime(20) || !p.ProbablyPrime(20) {
+			t.Errorf("N = %s is not the product of two safe primes", a.N)
+		}
+	}
+
+	var err error
+	if _, err = NewRSAAccumulator(rand.Reader, MinRSABits-1); err != ErrModulusSize {
+		t.Errorf("small modulus: %v, expected %v", err, ErrModulusSize)
+	}
+}
+
+func TestRSAAccumulatorMembership(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M")
+
+	var element []byte
+	for _, element = range a.Members() {
+
+		var w *big.Int
+		if w, err = a.MembershipWitness(element); err != nil {
+			t.Fatalf("witness of %s failed: %v", element, err)
+		}
+
+		if !a.VerifyMembership(element, w) {
+			t.Errorf("expected the witness of %s to verify", element)
+		}
+
+		if a.VerifyMembership([]byte("Z"), w) {
+			t.Errorf("expected the witness of %s not to verify for Z", element)
+		}
+	}
+
+	if _, err = a.MembershipWitness([]byte("Z")); err != ErrNotMember {
+		t.Errorf("witness of a non-member: %v, expected %v", err, ErrNotMember)
+	}
+
+	if err = a.Add([]byte("E")); err != ErrMember {
+		t.Errorf("adding a member twice: %v, expected %v", err, ErrMember)
+	}
+}
+
+func TestRSAAccumulatorDelete(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M")
+
+	var w *big.Int
+	if w, err = a.MembershipWitness([]byte("K")); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if err = a.Delete([]byte("K")); err != nil {
+		t.Fatalf("deletion failed: %v", err)
+	}
+
+	if a.VerifyMembership([]byte("K"), w) {
+		t.Error("expected the witness of a deleted element not to verify")
+	}
+
+	// Deleting with the trapdoor gives the same value as accumulating the
+	// remaining elements.
+	var expected = new(big.Int).Set(a.G)
+
+	var element []byte
+	for _, element = range a.Members() {
+		expected.Exp(expected, hashToPrime(element), a.N)
+	}
+
+	if a.Value.Cmp(expected) != 0 {
+		t.Errorf("value = %s, expected %s", a.Value, expected)
+	}
+
+	if len(a.Members()) != 3 || string(a.Members()[2]) != "M" {
+		t.Errorf("members = %q, expected B, E and M", a.Members())
+	}
+
+	if err = a.Delete([]byte("K")); err != ErrNotMember {
+		t.Errorf("deleting a non-member: %v, expected %v", err, ErrNotMember)
+	}
+
+	var public = &RSAAccumulator{N: a.N, G: a.G, Value: a.Value}
+
+	if err = public.Delete([]byte("E")); err != ErrTrapdoor {
+		t.Errorf("deleting without the trapdoor: %v, expected %v", err, ErrTrapdoor)
+	}
+}
+
+func TestRSAAccumulatorUpdate(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M")
+
+	var w *big.Int
+	if w, err = a.MembershipWitness([]byte("E")); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	var element string
+	for _, element = range []string{"P", "Q"} {
+		if err = a.Add([]byte(element)); err != nil {
+			t.Fatalf("adding %s failed: %v", element, err)
+		}
+	}
+
+	w = a.UpdateOnAdd(w, []byte("P"), []byte("Q"))
+
+	if !a.VerifyMembership([]byte("E"), w) {
+		t.Fatal("expected the witness to verify after the additions")
+	}
+
+	for _, element = range []string{"B", "P", "M"} {
+		if err = a.Delete([]byte(element)); err != nil {
+			t.Fatalf("deleting %s failed: %v", element, err)
+		}
+	}
+
+	if w, err = a.UpdateOnDelete([]byte("E"), w, []byte("B"), []byte("P"), []byte("M")); err != nil {
+		t.Fatalf("updating failed: %v", err)
+	}
+
+	if !a.VerifyMembership([]byte("E"), w) {
+		t.Fatal("expected the witness to verify after the deletions")
+	}
+
+	// The updates commute, so additions and deletions can be batched.
+	var v *big.Int
+	if v, err = a.MembershipWitness([]byte("K")); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if err = a.Add([]byte("R")); err != nil {
+		t.Fatalf("adding R failed: %v", err)
+	}
+
+	if err = a.Delete([]byte("Q")); err != nil {
+		t.Fatalf("deleting Q failed: %v", err)
+	}
+
+	if v, err = a.UpdateOnDelete([]byte("K"), a.UpdateOnAdd(v, []byte("R")), []byte("Q")); err != nil {
+		t.Fatalf("updating failed: %v", err)
+	}
+
+	if !a.VerifyMembership([]byte("K"), v) {
+		t.Error("expected the witness to verify after the batch")
+	}
+
+	if _, err = a.UpdateOnDelete([]byte("K"), v, []byte("K")); err != ErrNotMember {
+		t.Errorf("updating for the deletion of the member: %v, expected %v", err, ErrNotMember)
+	}
+}
+
+func TestRSAAccumulatorNonMembership(t *testing.T) {
+
+	var err error
+
+	var a = accumulate(t, "B", "E", "K", "M")
+
+	var element string
+	for _, element = range []string{"A", "C", "Z"} {
+
+		var w *NonMembershipWitness
+		if w, err = a.NonMembershipWitness([]byte(element)); err != nil {
+			t.Fatalf("witness of %s failed: %v", element, err)
+		}
+
+		if !a.VerifyNonMembership([]byte(element), w) {
+			t.Errorf("expected the witness of %s to verify", element)
+		}
+
+		if a.VerifyNonMembership([]byte("E"), w) {
+			t.Errorf("expected the witness of %s not to verify for the member E", element)
+		}
+	}
+
+	for _, element = range []string{"B", "E", "K", "M"} {
+		if _, err = a.NonMembershipWitness([]byte(element)); err != ErrMember {
+			t.Errorf("witness of the member %s: %v, expected %v", element, err, ErrMember)
+		}
+	}
+
+	var w *NonMembershipWitness
+	if w, err = a.NonMembershipWitness([]byte("Z")); err != nil {
+		t.Fatalf("witness failed: %v", err)
+	}
+
+	if err = a.Add([]byte("Z")); err != nil {
+		t.Fatalf("adding Z failed: %v", err)
+	}
+
+	if a.VerifyNonMembership([]byte("Z"), w) {
+		t.Error("expected the witness not to verify once Z is added")
+	}
+
+	// The empty set has no members, where a = 1 / 1 mod u.
+	var empty = accumulate(t)
+
+	if w, err = empty.NonMembershipWitness([]byte("Z")); err != nil || !empty.VerifyNonMembership([]byte("Z"), w) {
+		t.Errorf("expected Z not to be a member of the empty set: %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package snark
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// ErrDivisionByZero is returned by Solve when the divisor of Div or Inverse is
+// zero for the inputs.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Variable is a value in a circuit, kept as a linear combination of wires.
+// Adding, subtracting and scaling variables only changes the combination, so
+// the constraints are spent on multiplications and assertions alone.
+type Variable struct {
+	lc qap.LinearCombination
+}
+
+// LinearCombination returns the combination of wires equal to the variable.
+func (v Variable) LinearCombination() qap.LinearCombination {
+	return v.lc
+}
+
+// IsConstant reports whether the variable only uses the constant wire 0.
+func (v Variable) IsConstant() bool {
+
+	var term qap.Term
+	for _, term = range v.lc {
+		if term.Index != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Gate is a multiplication gate of a circuit, which sets the wire Output to
+// the product of two linear combinations of other wires with the constraint
+// at index Constraint of the R1CS.
+type Gate struct {
+	Left, Right qap.LinearCombination
+	Output      int
+	Constraint  int
+}
+
+// Hint computes the value of a wire from the values of the wires added before
+// it, which are the only ones set in values.
+type Hint func(values []*field.Element) (*field.Element, error)
+
+// Circuit builds a R1CS from inputs, arithmetic and assertions, e.g.
+//
+//	var c = NewCircuit(f)
+//	var x = c.PrivateInput("x")
+//	var out = c.PublicInput("out")
+//	c.AssertEqual(c.Add(c.Mul(c.Mul(x, x), x), x, c.Constant(5)), out)
+//
+// for x^3 + x + 5 = out in three constraints. Operations on constants are
+// folded, so they do not add constraints either.
+type Circuit struct {
+	Field *field.Field
+
+	// Gates lists the multiplications in the order they were added.
+	Gates []Gate
+
+	r1cs *qap.R1CS
+
+	// inputs maps the name of every input to its wire, and hints computes
+	// every wire that is not an input.
+	inputs   map[string]int
+	hints    map[int]Hint
+	internal int
+}
+
+// NewCircuit creates an empty circuit over the field.
+func NewCircuit(f *field.Field) *Circuit {
+	return &Circuit{Field: f, r1cs: qap.NewR1CS(f), inputs: make(map[string]int), hints: make(map[int]Hint)}
+}
+
+// R1CS returns the constraint system built so far. The variables are the
+// wires of the circuit: the constant, the inputs and the gate outputs in the
+// order they were added.
+func (c *Circuit) R1CS() *qap.R1CS {
+	return c.r1cs
+}
+
+// PublicInput adds a wire whose value is revealed to the verifier. It panics
+// if the circuit has an input of the same name already.
+func (c *Circuit) PublicInput(name string) Variable {
+	return c.input(name, true)
+}
+
+// PrivateInput adds a wire whose value is only known to the prover. It panics
+// if the circuit has an input of the same name already.
+func (c *Circuit) PrivateInput(name string) Variable {
+	return c.input(name, false)
+}
+
+// One returns the constant 1.
+func (c *Circuit) One() Variable {
+	return c.wire(0)
+}
+
+// Constant returns a small (possibly negative) constant.
+func (c *Circuit) Constant(x int64) Variable {
+	return c.ConstantElement(c.Field.NewInt64(x))
+}
+
+// ConstantElement returns a constant of the field.
+func (c *Circuit) ConstantElement(x *field.Element) Variable {
+	return c.Scale(c.One(), x)
+}
+
+// Add returns the sum of the variables.
+func (c *Circuit) Add(vs ...Variable) Variable {
+
+	var terms qap.LinearCombination
+
+	var v Variable
+	for _, v = range vs {
+		terms = append(terms, v.lc...)
+	}
+
+	return Variable{lc: combine(nil, terms, c.Field.One())}
+}
+
+// Sub returns a - b.
+func (c *Circuit) Sub(a, b Variable) Variable {
+	return Variable{lc: combine(a.lc, b.lc, c.Field.NewInt64(-1))}
+}
+
+// Neg returns -a.
+func (c *Circuit) Neg(a Variable) Variable {
+	return c.Scale(a, c.Field.NewInt64(-1))
+}
+
+// Scale returns x * a.
+func (c *Circuit) Scale(a Variable, x *field.Element) Variable {
+	return Variable{lc: scale(a.lc, x)}
+}
+
+// Mul returns a * b. If either operand is a constant the other one is
+// scaled, otherwise a gate with a new private wire is added.
+func (c *Circuit) Mul(a, b Variable) Variable {
+
+	if a.IsConstant() {
+		return c.Scale(b, c.constant(a))
+	}
+
+	if b.IsConstant() {
+		return c.Scale(a, c.constant(b))
+	}
+
+	var product = c.Compute(func(values []*field.Element) (*field.Element, error) {
+		return new(field.Element).Mul(a.lc.Evaluate(c.Field, values), b.lc.Evaluate(c.Field, values)), nil
+	})
+
+	var output = product.lc[0].Index
+
+	c.Gates = append(c.Gates, Gate{Left: a.lc, Right: b.lc, Output: output, Constraint: len(c.r1cs.Constraints)})
+	c.r1cs.AddConstraint(a.lc, b.lc, product.lc)
+
+	return product
+}
+
+// Div returns a / b. If b is a non-zero constant a is scaled, otherwise a new
+// private wire w is added with the constraint b * w = a, which can not be
+// satisfied when b is zero and a is not. When both are zero any w satisfies
+// it, so 0 / 0 is not constrained; use Mul(a, Inverse(b)) if b must not be
+// zero.
+func (c *Circuit) Div(a, b Variable) Variable {
+
+	if b.IsConstant() && !c.constant(b).IsZero() {
+		return c.Scale(a, new(field.Element).Inv(c.constant(b)))
+	}
+
+	var quotient = c.Compute(func(values []*field.Element) (*field.Element, error) {
+
+		var divisor = b.lc.Evaluate(c.Field, values)
+		if divisor.IsZero() {
+			return nil, ErrDivisionByZero
+		}
+
+		return new(field.Element).Mul(a.lc.Evaluate(c.Field, values), divisor.Inv(divisor)), nil
+	})
+
+	c.r1cs.AddConstraint(b.lc, quotient.lc, a.lc)
+
+	return quotient
+}
+
+// Inverse returns 1 / a.
+func (c *Circuit) Inverse(a Variable) Variable {
+	return c.Div(c.One(), a)
+}
+
+// Compute adds a private wire whose value Solve derives with the hint. The
+// wire is not constrained, so the caller must add constraints that only hold
+// for the value the hint computes.
+func (c *Circuit) Compute(hint Hint) Variable {
+
+	c.internal++
+
+	var index = c.r1cs.AddVariable(fmt.Sprintf("w%d", c.internal), false)
+	c.hints[index] = hint
+
+	return c.wire(index)
+}
+
+// AssertEqual adds the constraint (a - b) * 1 = 0. Nothing is added if the
+// difference is the constant 0; any other constant difference makes the
+// circuit unsatisfiable, and is kept so that IsSatisfied reports it.
+func (c *Circuit) AssertEqual(a, b Variable) {
+
+	var difference = c.Sub(a, b)
+
+	if len(difference.lc) == 0 {
+		return
+	}
+
+	c.r1cs.AddConstraint(difference.lc, c.One().lc, nil)
+}
+
+// Output makes the value of v public under the given name. If v is exactly
+// the output of the last gate, and that wire is not an output already, it is
+// revealed and renamed. Otherwise a public wire named name is added with the
+// constraint 1 * v = name.
+func (c *Circuit) Output(name string, v Variable) Variable {
+
+	if len(c.Gates) > 0 && len(v.lc) == 1 && v.lc[0].Coeff.IsOne() &&
+		v.lc[0].Index == c.Gates[len(c.Gates)-1].Output && !c.r1cs.Variables[v.lc[0].Index].Public {
+
+		c.r1cs.Variables[v.lc[0].Index].Public = true
+		c.r1cs.Variables[v.lc[0].Index].Name = name
+
+		return v
+	}
+
+	var out = c.wire(c.r1cs.AddVariable(name, true))
+
+	c.hints[out.lc[0].Index] = func(values []*field.Element) (*field.Element, error) {
+		return v.lc.Evaluate(c.Field, values), nil
+	}
+
+	c.r1cs.AddConstraint(c.One().lc, v.lc, out.lc)
+
+	return out
+}
+
+// input adds the wire of an input, which Solve looks up by its name.
+func (c *Circuit) input(name string, public bool) Variable {
+
+	var _, ok = c.inputs[name]
+	if ok {
+		panic(fmt.Sprintf("snark: input %q is declared twice", name))
+	}
+
+	var index = c.r1cs.AddVariable(name, public)
+	c.inputs[name] = index
+
+	return c.wire(index)
+}
+
+func (c *Circuit) wire(index int) Variable {
+	return Variable{lc: qap.LinearCombination{c.r1cs.NewTerm(index, 1)}}
+}
+
+// constant returns the value of a constant variable.
+func (c *Circuit) constant(v Variable) *field.Element {
+	return v.lc.Evaluate(c.Field, []*field.Element{c.Field.One()})
+}
+
+// combine returns a + sign * b, merging the terms of the same wire and
+// dropping those that cancel.
+func combine(a, b qap.LinearCombination, sign *field.Element) qap.LinearCombination {
+
+	var sum = make(qap.LinearCombination, 0, len(a)+len(b))
+
+	var positions = make(map[int]int)
+
+	var term qap.Term
+	for _, term = range a {
+		positions[term.Index] = len(sum)
+		sum = append(sum, qap.Term{Index: term.Index, Coeff: new(field.Element).Set(term.Coeff)})
+	}
+
+	for _, term = range b {
+
+		var coeff = new(field.Element).Mul(sign, term.Coeff)
+
+		var position, ok = positions[term.Index]
+		if ok {
+			sum[position].Coeff.Add(sum[position].Coeff, coeff)
+			continue
+		}
+
+		positions[term.Index] = len(sum)
+		sum = append(sum, qap.Term{Index: term.Index, Coeff: coeff})
+	}
+
+	var trimmed = sum[:0]
+	for _, term = range sum {
+		if !term.Coeff.IsZero() {
+			trimmed = append(trimmed, term)
+		}
+	}
+
+	return trimmed
+}
+
+// scale returns x * a, which is empty if x is zero.
+func scale(a qap.LinearCombination, x *field.Element) qap.LinearCombination {
+
+	var scaled qap.LinearCombination
+
+	if x.IsZero() {
+		return scaled
+	}
+
+	var term qap.Term
+	for _, term = range a {
+		scaled = append(scaled, qap.Term{Index: term.Index, Coeff: new(field.Element).Mul(x, term.Coeff)})
+	}
+
+	return scaled
+}
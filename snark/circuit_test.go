@@ -0,0 +1,164 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestCircuit(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// x^3 + x + 5 = out from example 3 of the qap package.
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var out = c.PublicInput("out")
+
+	c.AssertEqual(c.Add(c.Mul(c.Mul(x, x), x), x, c.Constant(5)), out)
+
+	var r1cs = c.R1CS()
+
+	if len(r1cs.Constraints) != 3 || len(c.Gates) != 2 {
+		t.Errorf("constraints = %d, gates = %d, expected 3, 2", len(r1cs.Constraints), len(c.Gates))
+	}
+
+	var public = r1cs.Public()
+	if len(public) != 2 || public[1] != 2 {
+		t.Errorf("public = %v, expected [0 2]", public)
+	}
+
+	// one, x, out, w1 = x * x, w2 = w1 * x
+	var witness = []*field.Element{
+		f.NewInt64(1), f.NewInt64(3), f.NewInt64(35), f.NewInt64(9), f.NewInt64(27),
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness to satisfy the circuit: %v", err)
+	}
+
+	witness[2] = f.NewInt64(36)
+
+	if err = r1cs.IsSatisfied(witness); err == nil {
+		t.Errorf("expected a wrong output not to satisfy the circuit")
+	}
+}
+
+func TestCircuitConstantFolding(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+
+	// (2 * 3 - 6) * x + 4 * (x - x) is the constant 0 and needs no gates.
+	var zero = c.Add(c.Mul(c.Sub(c.Mul(c.Constant(2), c.Constant(3)), c.Constant(6)), x), c.Scale(c.Sub(x, x), f.NewInt64(4)))
+
+	if !zero.IsConstant() || len(zero.LinearCombination()) != 0 {
+		t.Errorf("expected the constant 0, got %v", zero.LinearCombination())
+	}
+
+	c.AssertEqual(zero, c.Constant(0))
+	c.AssertEqual(c.Add(x, c.One()), c.Add(c.One(), x))
+
+	if len(c.R1CS().Constraints) != 0 {
+		t.Errorf("constraints = %d, expected 0", len(c.R1CS().Constraints))
+	}
+
+	// An assertion between different constants can never hold.
+	c.AssertEqual(c.Constant(1), c.Constant(2))
+
+	if c.R1CS().IsSatisfied([]*field.Element{f.One(), f.NewInt64(7)}) == nil {
+		t.Errorf("expected 1 = 2 not to be satisfied")
+	}
+}
+
+func TestCircuitOutput(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var y = c.PrivateInput("y")
+
+	// The product is the last gate, so its wire is made public.
+	var product = c.Output("product", c.Mul(x, y))
+
+	// The sum is a linear combination and needs a wire of its own.
+	var sum = c.Output("sum", c.Add(x, y))
+
+	var r1cs = c.R1CS()
+
+	if len(r1cs.Constraints) != 2 {
+		t.Errorf("constraints = %d, expected 2", len(r1cs.Constraints))
+	}
+
+	if product.LinearCombination()[0].Index != 3 || sum.LinearCombination()[0].Index != 4 {
+		t.Errorf("outputs = %v, %v, expected wires 3 and 4", product.LinearCombination(), sum.LinearCombination())
+	}
+
+	// one, x, y, w1 = x * y, sum = x + y
+	var witness = []*field.Element{
+		f.NewInt64(1), f.NewInt64(3), f.NewInt64(4), f.NewInt64(12), f.NewInt64(7),
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness to satisfy the circuit: %v", err)
+	}
+
+	var public = r1cs.Public()
+	if len(public) != 3 || public[1] != 3 || public[2] != 4 {
+		t.Errorf("public = %v, expected [0 3 4]", public)
+	}
+}
+
+func TestCircuitOutputTwice(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var y = c.PrivateInput("y")
+
+	var product = c.Mul(x, y)
+
+	// The first output renames the wire of the gate, the second one copies it
+	// so that both names stay public.
+	c.Output("o1", product)
+	c.Output("o2", product)
+
+	var r1cs = c.R1CS()
+
+	if len(r1cs.Constraints) != 2 {
+		t.Errorf("constraints = %d, expected 2", len(r1cs.Constraints))
+	}
+
+	var name string
+	for _, name = range []string{"o1", "o2"} {
+
+		var index, ok = r1cs.Variable(name)
+		if !ok || !r1cs.Variables[index].Public {
+			t.Errorf("expected a public output %q", name)
+		}
+	}
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "y": f.NewInt64(4)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	if len(witness) != 5 || !witness[3].Equal(f.NewInt64(12)) || !witness[4].Equal(f.NewInt64(12)) {
+		t.Errorf("witness = %v, expected both outputs to be 12", witness)
+	}
+}
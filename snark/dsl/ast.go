@@ -0,0 +1,190 @@
+// Package dsl compiles circuits written in a small language to the R1CS of
+// package qap, through the circuit builder of package snark. A program is a
+// list of functions, one of which is main, e.g.
+//
+//	def cube(x) -> field:
+//	    return x * x * x
+//
+//	def main(private x, public out):
+//	    assert cube(x) + x + 5 == out
+//
+// Blocks are indented as in Python and comments start with #. The values are
+// elements of the field, or booleans from comparisons which are represented
+// by 0 and 1. Loops have constant bounds and are unrolled, and functions are
+// inlined at every call, so recursion is not allowed.
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Pos is a position in the source, counted from line 1 and column 1.
+type Pos struct {
+	Line, Column int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Error reports a syntax or type error at a position in the source.
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos Pos, format string, args ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Type is the type of a value, or None for a function without a result.
+type Type int
+
+// The types of the language.
+const (
+	None Type = iota
+	Field
+	Bool
+)
+
+func (t Type) String() string {
+
+	switch t {
+	case Field:
+		return "field"
+	case Bool:
+		return "bool"
+	}
+
+	return "none"
+}
+
+// Visibility tells whether a parameter of main is a public or private input
+// of the circuit. The parameters of the other functions have none.
+type Visibility int
+
+// The visibilities of parameters.
+const (
+	Local Visibility = iota
+	Private
+	Public
+)
+
+// Program is a parsed source file.
+type Program struct {
+	Functions []*Function
+}
+
+// Function is a function definition. Its parameters are fields unless
+// declared otherwise, and Result is None if it does not return a value.
+type Function struct {
+	Pos    Pos
+	Name   string
+	Params []*Param
+	Result Type
+	Body   []Stmt
+}
+
+// Param is a parameter of a function.
+type Param struct {
+	Pos        Pos
+	Name       string
+	Type       Type
+	Visibility Visibility
+}
+
+// Stmt is one of *AssertStmt, *ReturnStmt, *ForStmt and *AssignStmt.
+type Stmt interface {
+	stmt()
+}
+
+// AssertStmt requires a boolean expression to be true.
+type AssertStmt struct {
+	Pos  Pos
+	Cond Expr
+}
+
+// ReturnStmt ends a function with its result.
+type ReturnStmt struct {
+	Pos   Pos
+	Value Expr
+}
+
+// ForStmt runs its body for Var from From up to To excluded, which must be
+// constants.
+type ForStmt struct {
+	Pos      Pos
+	Var      string
+	From, To Expr
+	Body     []Stmt
+}
+
+// AssignStmt declares a variable if Type is set or the name is new, and
+// assigns an existing one otherwise.
+type AssignStmt struct {
+	Pos   Pos
+	Type  Type
+	Name  string
+	Value Expr
+}
+
+func (*AssertStmt) stmt() {}
+func (*ReturnStmt) stmt() {}
+func (*ForStmt) stmt()    {}
+func (*AssignStmt) stmt() {}
+
+// Expr is one of *Number, *Boolean, *Name, *Call, *Unary and *Binary.
+type Expr interface {
+	pos() Pos
+}
+
+// Number is a non-negative integer constant.
+type Number struct {
+	Pos   Pos
+	Value *big.Int
+}
+
+// Boolean is true or false.
+type Boolean struct {
+	Pos   Pos
+	Value bool
+}
+
+// Name is a reference to a variable or parameter.
+type Name struct {
+	Pos  Pos
+	Name string
+}
+
+// Call is a call of a function with a result.
+type Call struct {
+	Pos  Pos
+	Name string
+	Args []Expr
+}
+
+// Unary is "-" or "not" applied to an operand.
+type Unary struct {
+	Pos Pos
+	Op  string
+	X   Expr
+}
+
+// Binary is an arithmetic, comparison or logical operation.
+type Binary struct {
+	Pos         Pos
+	Op          string
+	Left, Right Expr
+}
+
+func (e *Number) pos() Pos  { return e.Pos }
+func (e *Boolean) pos() Pos { return e.Pos }
+func (e *Name) pos() Pos    { return e.Pos }
+func (e *Call) pos() Pos    { return e.Pos }
+func (e *Unary) pos() Pos   { return e.Pos }
+func (e *Binary) pos() Pos  { return e.Pos }
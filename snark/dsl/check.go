@@ -0,0 +1,406 @@
+package dsl
+
+// Check verifies the names and types of a program:
+//
+//   - main is defined, only its parameters are private or public inputs, and
+//     they all are; it may return a field, which becomes the public output
+//     "out". Its parameters can not be named "one", "w1", "w2", ... like the
+//     wires of the circuit, nor "out" if it returns.
+//   - Arithmetic and < <= > >= take fields, "and", "or" and "not" take
+//     booleans, == and != take two values of the same type, and assertions
+//     take booleans.
+//   - A function with a result ends with its only return, and the others do
+//     not return.
+//   - The bounds of loops only use numbers and the variables of outer loops,
+//     which can not be assigned, so the loops can be unrolled.
+//   - Functions do not call themselves, directly or not, so they can be
+//     inlined.
+func Check(program *Program) error {
+
+	var err error
+
+	var c = &checker{functions: make(map[string]*Function), calls: make(map[string][]string)}
+
+	var function *Function
+	for _, function = range program.Functions {
+
+		if c.functions[function.Name] != nil {
+			return errorf(function.Pos, "%s is defined twice", function.Name)
+		}
+
+		c.functions[function.Name] = function
+	}
+
+	if c.functions["main"] == nil {
+		return errorf(Pos{Line: 1, Column: 1}, "no main function")
+	}
+
+	for _, function = range program.Functions {
+		if err = c.function(function); err != nil {
+			return err
+		}
+	}
+
+	var state = make(map[string]int)
+
+	for _, function = range program.Functions {
+		if err = c.recursion(function.Name, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type symbol struct {
+	typ  Type
+	loop bool
+}
+
+type checker struct {
+	functions map[string]*Function
+
+	// calls lists the functions called by every function.
+	calls map[string][]string
+
+	current *Function
+	scopes  []map[string]*symbol
+}
+
+func (c *checker) function(function *Function) error {
+
+	var err error
+
+	var main = function.Name == "main"
+
+	if main && function.Result == Bool {
+		return errorf(function.Pos, "main can only return a field")
+	}
+
+	c.current = function
+	c.scopes = []map[string]*symbol{make(map[string]*symbol)}
+
+	var param *Param
+	for _, param = range function.Params {
+
+		switch {
+		case main && param.Visibility == Local:
+			return errorf(param.Pos, "parameter %s of main must be private or public", param.Name)
+		case !main && param.Visibility != Local:
+			return errorf(param.Pos, "only the parameters of main can be inputs")
+		case c.scopes[0][param.Name] != nil:
+			return errorf(param.Pos, "parameter %s is declared twice", param.Name)
+		case main && (reserved(param.Name) || param.Name == "out" && function.Result != None):
+			return errorf(param.Pos, "parameter %s of main has the name of a wire of the circuit", param.Name)
+		}
+
+		c.scopes[0][param.Name] = &symbol{typ: param.Type}
+	}
+
+	if err = c.block(function.Body); err != nil {
+		return err
+	}
+
+	if function.Result == None {
+		return nil
+	}
+
+	var ok bool
+	if len(function.Body) > 0 {
+		_, ok = function.Body[len(function.Body)-1].(*ReturnStmt)
+	}
+
+	if !ok {
+		return errorf(function.Pos, "%s must end with a return", function.Name)
+	}
+
+	return nil
+}
+
+// reserved reports whether the name is one the circuit gives its own wires,
+// the constant one or an internal w1, w2, ....
+func reserved(name string) bool {
+
+	if name == "one" {
+		return true
+	}
+
+	if len(name) < 2 || name[0] != 'w' {
+		return false
+	}
+
+	var r rune
+	for _, r = range name[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *checker) block(statements []Stmt) error {
+
+	var err error
+
+	var i int
+	var statement Stmt
+
+	for i, statement = range statements {
+
+		switch s := statement.(type) {
+		case *AssertStmt:
+
+			if err = c.expect(s.Cond, Bool); err != nil {
+				return err
+			}
+
+		case *ReturnStmt:
+
+			var last = len(c.scopes) == 1 && i == len(statements)-1
+
+			switch {
+			case c.current.Result == None:
+				return errorf(s.Pos, "%s does not return a value", c.current.Name)
+			case !last:
+				return errorf(s.Pos, "return must be the last statement of %s", c.current.Name)
+			}
+
+			if err = c.expect(s.Value, c.current.Result); err != nil {
+				return err
+			}
+
+		case *ForStmt:
+
+			var bound Expr
+			for _, bound = range []Expr{s.From, s.To} {
+
+				if err = c.constant(bound); err != nil {
+					return err
+				}
+			}
+
+			c.scopes = append(c.scopes, map[string]*symbol{s.Var: {typ: Field, loop: true}})
+
+			if err = c.block(s.Body); err != nil {
+				return err
+			}
+
+			c.scopes = c.scopes[:len(c.scopes)-1]
+
+		case *AssignStmt:
+
+			var typ Type
+			if typ, err = c.expr(s.Value); err != nil {
+				return err
+			}
+
+			var scope = c.scopes[len(c.scopes)-1]
+			var existing = c.lookup(s.Name)
+
+			switch {
+			case s.Type != None && scope[s.Name] != nil:
+				return errorf(s.Pos, "%s is declared twice", s.Name)
+			case s.Type != None && s.Type != typ:
+				return errorf(s.Pos, "can not assign %s to %s %s", typ, s.Type, s.Name)
+			case s.Type != None || existing == nil:
+				scope[s.Name] = &symbol{typ: typ}
+			case existing.loop:
+				return errorf(s.Pos, "can not assign to loop variable %s", s.Name)
+			case existing.typ != typ:
+				return errorf(s.Pos, "can not assign %s to %s %s", typ, existing.typ, s.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) lookup(name string) *symbol {
+
+	var i int
+	for i = len(c.scopes) - 1; i >= 0; i-- {
+		if c.scopes[i][name] != nil {
+			return c.scopes[i][name]
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) expect(e Expr, typ Type) error {
+
+	var err error
+
+	var actual Type
+	if actual, err = c.expr(e); err != nil {
+		return err
+	}
+
+	if actual != typ {
+		return errorf(e.pos(), "expected %s, found %s", typ, actual)
+	}
+
+	return nil
+}
+
+func (c *checker) expr(e Expr) (Type, error) {
+
+	var err error
+
+	switch e := e.(type) {
+	case *Number:
+		return Field, nil
+
+	case *Boolean:
+		return Bool, nil
+
+	case *Name:
+
+		var s = c.lookup(e.Name)
+		if s == nil {
+			return None, errorf(e.Pos, "undefined: %s", e.Name)
+		}
+
+		return s.typ, nil
+
+	case *Call:
+
+		var function = c.functions[e.Name]
+
+		switch {
+		case function == nil:
+			return None, errorf(e.Pos, "undefined function: %s", e.Name)
+		case e.Name == "main":
+			return None, errorf(e.Pos, "main can not be called")
+		case function.Result == None:
+			return None, errorf(e.Pos, "%s does not return a value", e.Name)
+		case len(e.Args) != len(function.Params):
+			return None, errorf(e.Pos, "%s takes %d arguments, not %d", e.Name, len(function.Params), len(e.Args))
+		}
+
+		var i int
+		for i = range e.Args {
+			if err = c.expect(e.Args[i], function.Params[i].Type); err != nil {
+				return None, err
+			}
+		}
+
+		c.calls[c.current.Name] = append(c.calls[c.current.Name], e.Name)
+
+		return function.Result, nil
+
+	case *Unary:
+
+		var typ = Field
+		if e.Op == "not" {
+			typ = Bool
+		}
+
+		return typ, c.expect(e.X, typ)
+
+	case *Binary:
+
+		switch e.Op {
+		case "==", "!=":
+
+			var left Type
+			if left, err = c.expr(e.Left); err != nil {
+				return None, err
+			}
+
+			return Bool, c.expect(e.Right, left)
+
+		case "and", "or":
+
+			if err = c.expect(e.Left, Bool); err != nil {
+				return None, err
+			}
+
+			return Bool, c.expect(e.Right, Bool)
+		}
+
+		if err = c.expect(e.Left, Field); err != nil {
+			return None, err
+		}
+
+		if err = c.expect(e.Right, Field); err != nil {
+			return None, err
+		}
+
+		switch e.Op {
+		case "<", "<=", ">", ">=":
+			return Bool, nil
+		}
+
+		return Field, nil
+	}
+
+	return None, errorf(e.pos(), "unknown expression")
+}
+
+// constant checks that an expression only uses numbers, loop variables and
+// the operations + - *.
+func (c *checker) constant(e Expr) error {
+
+	var err error
+
+	switch e := e.(type) {
+	case *Number:
+		return nil
+
+	case *Name:
+
+		var s = c.lookup(e.Name)
+		if s != nil && s.loop {
+			return nil
+		}
+
+	case *Unary:
+
+		if e.Op == "-" {
+			return c.constant(e.X)
+		}
+
+	case *Binary:
+
+		if e.Op == "+" || e.Op == "-" || e.Op == "*" {
+
+			if err = c.constant(e.Left); err != nil {
+				return err
+			}
+
+			return c.constant(e.Right)
+		}
+	}
+
+	return errorf(e.pos(), "loop bounds must be constant")
+}
+
+// recursion follows the calls from the function with a depth first search,
+// where state is 1 for the functions being visited and 2 for the finished
+// ones, so a call to a function being visited closes a cycle.
+func (c *checker) recursion(name string, state map[string]int) error {
+
+	var err error
+
+	switch state[name] {
+	case 1:
+		return errorf(c.functions[name].Pos, "%s is recursive", name)
+	case 2:
+		return nil
+	}
+
+	state[name] = 1
+
+	var callee string
+	for _, callee = range c.calls[name] {
+		if err = c.recursion(callee, state); err != nil {
+			return err
+		}
+	}
+
+	state[name] = 2
+
+	return nil
+}
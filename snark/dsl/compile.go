@@ -0,0 +1,359 @@
+package dsl
+
+import (
+	"math/big"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/snark"
+)
+
+// ComparisonBits is the size of the operands of < <= > and >=, which are
+// compared as integers less than 2^ComparisonBits. Larger values make the
+// constraints of the comparison unsatisfiable.
+const ComparisonBits = 64
+
+// MaxIterations bounds the number of iterations of a loop, so a typo in a
+// bound does not unroll it forever.
+const MaxIterations = 1 << 20
+
+// Compile parses and checks the program and builds its circuit over the
+// field. The inputs of the circuit are the parameters of main in order, and
+// its result, if any, is the public output "out". The constraints are in
+// R1CS of the circuit, and its Solve computes a witness from the inputs.
+func Compile(f *field.Field, source string) (*snark.Circuit, error) {
+
+	var err error
+
+	var program *Program
+	if program, err = Parse(source); err != nil {
+		return nil, err
+	}
+
+	if err = Check(program); err != nil {
+		return nil, err
+	}
+
+	var c = &compiler{c: snark.NewCircuit(f), functions: make(map[string]*Function)}
+
+	var function *Function
+	for _, function = range program.Functions {
+		c.functions[function.Name] = function
+	}
+
+	var main = c.functions["main"]
+	var args = make([]snark.Variable, len(main.Params))
+
+	var i int
+	var param *Param
+
+	for i, param = range main.Params {
+
+		if param.Visibility == Public {
+			args[i] = c.c.PublicInput(param.Name)
+		} else {
+			args[i] = c.c.PrivateInput(param.Name)
+		}
+
+		if param.Type == Bool {
+			c.c.AssertBoolean(args[i])
+		}
+	}
+
+	var result snark.Variable
+	if result, err = c.call(main, args); err != nil {
+		return nil, err
+	}
+
+	if main.Result != None {
+		c.c.Output("out", result)
+	}
+
+	return c.c, nil
+}
+
+type compiler struct {
+	c         *snark.Circuit
+	functions map[string]*Function
+
+	// scopes holds the values of the variables of the function being
+	// inlined, from the parameters to the innermost loop.
+	scopes []map[string]snark.Variable
+}
+
+// call inlines the function with the arguments and returns its result.
+func (c *compiler) call(function *Function, args []snark.Variable) (snark.Variable, error) {
+
+	var caller = c.scopes
+
+	c.scopes = []map[string]snark.Variable{make(map[string]snark.Variable)}
+
+	var i int
+	for i = range args {
+		c.scopes[0][function.Params[i].Name] = args[i]
+	}
+
+	var result, err = c.block(function.Body)
+
+	c.scopes = caller
+
+	return result, err
+}
+
+// block runs the statements and returns the value of the return statement
+// that may end them.
+func (c *compiler) block(statements []Stmt) (snark.Variable, error) {
+
+	var err error
+
+	var result snark.Variable
+
+	var statement Stmt
+	for _, statement = range statements {
+
+		switch s := statement.(type) {
+		case *AssertStmt:
+			err = c.assert(s.Cond)
+
+		case *ReturnStmt:
+			result, err = c.expr(s.Value)
+
+		case *ForStmt:
+			err = c.loop(s)
+
+		case *AssignStmt:
+
+			var value snark.Variable
+			if value, err = c.expr(s.Value); err != nil {
+				return result, err
+			}
+
+			c.assign(s, value)
+		}
+
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// assert compiles a == b to a single equality constraint, and any other
+// condition to the constraint that it equals 1.
+func (c *compiler) assert(cond Expr) error {
+
+	var err error
+
+	var left, right snark.Variable
+
+	var binary, ok = cond.(*Binary)
+	if ok && binary.Op == "==" {
+
+		if left, err = c.expr(binary.Left); err != nil {
+			return err
+		}
+
+		if right, err = c.expr(binary.Right); err != nil {
+			return err
+		}
+
+		c.c.AssertEqual(left, right)
+
+		return nil
+	}
+
+	if left, err = c.expr(cond); err != nil {
+		return err
+	}
+
+	c.c.AssertEqual(left, c.c.One())
+
+	return nil
+}
+
+func (c *compiler) loop(s *ForStmt) error {
+
+	var err error
+
+	var bounds = make([]*big.Int, 2)
+
+	var i int
+	var bound Expr
+
+	for i, bound = range []Expr{s.From, s.To} {
+
+		var value snark.Variable
+		if value, err = c.expr(bound); err != nil {
+			return err
+		}
+
+		bounds[i] = c.constant(value)
+	}
+
+	var iterations = new(big.Int).Sub(bounds[1], bounds[0])
+
+	if iterations.Cmp(big.NewInt(MaxIterations)) > 0 {
+		return errorf(s.Pos, "loop of %s iterations is larger than %d", iterations, MaxIterations)
+	}
+
+	var k = new(big.Int).Set(bounds[0])
+
+	for ; k.Cmp(bounds[1]) < 0; k.Add(k, big.NewInt(1)) {
+
+		c.scopes = append(c.scopes, map[string]snark.Variable{
+			s.Var: c.c.ConstantElement(c.c.Field.NewElement(k)),
+		})
+
+		if _, err = c.block(s.Body); err != nil {
+			return err
+		}
+
+		c.scopes = c.scopes[:len(c.scopes)-1]
+	}
+
+	return nil
+}
+
+// assign sets the variable in the scope it was declared in, or declares it in
+// the innermost scope.
+func (c *compiler) assign(s *AssignStmt, value snark.Variable) {
+
+	var i int
+	for i = len(c.scopes) - 1; i >= 0 && s.Type == None; i-- {
+
+		var _, ok = c.scopes[i][s.Name]
+		if ok {
+			c.scopes[i][s.Name] = value
+			return
+		}
+	}
+
+	c.scopes[len(c.scopes)-1][s.Name] = value
+}
+
+func (c *compiler) lookup(name string) snark.Variable {
+
+	var i int
+	for i = len(c.scopes) - 1; i >= 0; i-- {
+
+		var value, ok = c.scopes[i][name]
+		if ok {
+			return value
+		}
+	}
+
+	return snark.Variable{}
+}
+
+func (c *compiler) expr(e Expr) (snark.Variable, error) {
+
+	var err error
+
+	switch e := e.(type) {
+	case *Number:
+		return c.c.ConstantElement(c.c.Field.NewElement(e.Value)), nil
+
+	case *Boolean:
+
+		if e.Value {
+			return c.c.One(), nil
+		}
+
+		return c.c.Constant(0), nil
+
+	case *Name:
+		return c.lookup(e.Name), nil
+
+	case *Call:
+
+		var args = make([]snark.Variable, len(e.Args))
+
+		var i int
+		for i = range e.Args {
+			if args[i], err = c.expr(e.Args[i]); err != nil {
+				return snark.Variable{}, err
+			}
+		}
+
+		return c.call(c.functions[e.Name], args)
+
+	case *Unary:
+
+		var x snark.Variable
+		if x, err = c.expr(e.X); err != nil {
+			return x, err
+		}
+
+		if e.Op == "not" {
+			return c.c.Not(x), nil
+		}
+
+		return c.c.Neg(x), nil
+
+	case *Binary:
+
+		var left, right snark.Variable
+
+		if left, err = c.expr(e.Left); err != nil {
+			return left, err
+		}
+
+		if right, err = c.expr(e.Right); err != nil {
+			return right, err
+		}
+
+		return c.binary(e.Op, left, right), nil
+	}
+
+	return snark.Variable{}, errorf(e.pos(), "unknown expression")
+}
+
+func (c *compiler) binary(op string, left, right snark.Variable) snark.Variable {
+
+	switch op {
+	case "+":
+		return c.c.Add(left, right)
+	case "-":
+		return c.c.Sub(left, right)
+	case "*":
+		return c.c.Mul(left, right)
+	case "/":
+		return c.c.Div(left, right)
+	case "and":
+		return c.c.And(left, right)
+	case "or":
+		return c.c.Or(left, right)
+	case "==":
+		return c.c.IsEqual(left, right)
+	case "!=":
+		return c.c.Not(c.c.IsEqual(left, right))
+	case ">":
+		return c.lessThan(right, left)
+	case "<=":
+		return c.c.Not(c.lessThan(right, left))
+	case ">=":
+		return c.c.Not(c.lessThan(left, right))
+	}
+
+	return c.lessThan(left, right)
+}
+
+// lessThan compares constants directly, which keeps comparisons of loop
+// variables free.
+func (c *compiler) lessThan(a, b snark.Variable) snark.Variable {
+
+	if !a.IsConstant() || !b.IsConstant() {
+		return c.c.LessThan(a, b, ComparisonBits)
+	}
+
+	if c.constant(a).Cmp(c.constant(b)) < 0 {
+		return c.c.One()
+	}
+
+	return c.c.Constant(0)
+}
+
+// constant returns the value of a constant variable.
+func (c *compiler) constant(v snark.Variable) *big.Int {
+	return v.LinearCombination().Evaluate(c.c.Field, []*field.Element{c.c.Field.One()}).Big()
+}
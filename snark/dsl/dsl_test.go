@@ -0,0 +1,159 @@
+package dsl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/snark"
+)
+
+// solve compiles the program and solves it for small inputs. The witness is
+// nil if the constraints are not satisfied.
+func solve(t *testing.T, source string, inputs map[string]int64) (*snark.Circuit, []*field.Element) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c *snark.Circuit
+	if c, err = Compile(f, source); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	var values = make(map[string]*field.Element)
+
+	var name string
+	for name = range inputs {
+		values[name] = f.NewInt64(inputs[name])
+	}
+
+	var witness []*field.Element
+	if witness, err = c.Solve(values); err != nil {
+		return c, nil
+	}
+
+	return c, witness
+}
+
+func TestCompile(t *testing.T) {
+
+	var source = "def main(private x, public out): assert x*x*x + x + 5 == out\n"
+
+	var c, witness = solve(t, source, map[string]int64{"x": 3, "out": 35})
+	if witness == nil {
+		t.Fatal("expected x = 3 to give 35")
+	}
+
+	// x * x, then * x, and the assertion.
+	if len(c.R1CS().Constraints) != 3 {
+		t.Errorf("constraints = %d, expected 3", len(c.R1CS().Constraints))
+	}
+
+	var public = c.R1CS().Public()
+	if len(public) != 2 || c.R1CS().Variables[public[1]].Name != "out" {
+		t.Errorf("public = %v, expected one and out", public)
+	}
+
+	if _, witness = solve(t, source, map[string]int64{"x": 3, "out": 36}); witness != nil {
+		t.Error("expected x = 3 not to give 36")
+	}
+}
+
+func TestCompileFunctionsAndLoops(t *testing.T) {
+
+	var source = strings.Join([]string{
+		"# x^3 by repeated multiplication",
+		"def cube(x) -> field:",
+		"    field y = 1",
+		"    for i in range(3):",
+		"        y = y * x",
+		"    return y",
+		"",
+		"def main(private x, private bool flip) -> field:",
+		"    field sum = 0",
+		"    for i in range(1, 4):",
+		"        for j in range(i):",
+		"            sum = sum + x",
+		"    assert sum == 6 * x",
+		"    assert not (x == 0) or flip",
+		"    assert small(x) and x >= 2",
+		"    return cube(x) + sum",
+		"",
+		"def small(x) -> bool:",
+		"    return x < 100",
+	}, "\n")
+
+	var c, witness = solve(t, source, map[string]int64{"x": 5, "flip": 0})
+	if witness == nil {
+		t.Fatal("expected x = 5 to satisfy the program")
+	}
+
+	var out, _ = c.R1CS().Variable("out")
+	if !witness[out].Equal(field.New(bn256.Order).NewInt64(125 + 30)) {
+		t.Errorf("out = %s, expected 155", witness[out])
+	}
+
+	var inputs = []map[string]int64{
+		{"x": 0, "flip": 0},   // x == 0 without flip
+		{"x": 1, "flip": 0},   // x < 2
+		{"x": 100, "flip": 0}, // not small
+		{"x": 5, "flip": 2},   // flip is not a boolean
+	}
+
+	var input map[string]int64
+	for _, input = range inputs {
+		if _, witness = solve(t, source, input); witness != nil {
+			t.Errorf("expected %v not to satisfy the program", input)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+
+	var tests = []struct {
+		source string
+		msg    string
+	}{
+		{"def main(private x): assert x $ 1\n", `1:31: unexpected '$'`},
+		{"def main(private x):\nassert x == 1\n", "2:1: expected indented block, found 'assert'"},
+		{"def main(private x):\n    assert x == 1\n  assert x == 2\n", "3:3: indentation does not match any outer block"},
+		{"def main(private x): assert 1 < x < 2\n", "1:35: comparisons can not be chained"},
+		{"def main(private x): assert x\n", "1:29: expected bool, found field"},
+		{"def main(private x): assert y == 1\n", "1:29: undefined: y"},
+		{"def main(x): assert x == 1\n", "1:10: parameter x of main must be private or public"},
+		{"def f(x) -> field: return x\n", "1:1: no main function"},
+		{"def main(private x):\n    for i in range(x): assert i == 1\n", "2:20: loop bounds must be constant"},
+		{"def main(private x):\n    for i in range(2):\n        i = x\n", "3:9: can not assign to loop variable i"},
+		{"def f(x) -> field: return g(x)\ndef g(x) -> field: return f(x)\ndef main(private x): assert f(x) == 1\n", "1:1: f is recursive"},
+		{"def f(x) -> field:\n    return x\n    assert x == 1\ndef main(private x): assert f(x) == 1\n", "2:5: return must be the last statement of f"},
+		{"def f(x) -> field: assert x == 1\ndef main(private x): assert f(x) == 1\n", "1:1: f must end with a return"},
+		{"def f(x, y) -> field: return x\ndef main(private x): assert f(x) == 1\n", "2:29: f takes 2 arguments, not 1"},
+		{"def main(private x):\n    bool b = x\n", "2:5: can not assign field to bool b"},
+		{"def main(private one): assert one == 1\n", "1:10: parameter one of main has the name of a wire of the circuit"},
+		{"def main(public w1): assert w1 == 1\n", "1:10: parameter w1 of main has the name of a wire of the circuit"},
+		{"def main(private x, public out) -> field: return x\n", "1:21: parameter out of main has the name of a wire of the circuit"},
+	}
+
+	var f = field.New(bn256.Order)
+
+	var test struct {
+		source string
+		msg    string
+	}
+
+	for _, test = range tests {
+
+		var err error
+		if _, err = Compile(f, test.source); err == nil {
+			t.Errorf("%q: expected an error", test.source)
+			continue
+		}
+
+		var e, ok = err.(*Error)
+		if !ok || e.Error() != test.msg {
+			t.Errorf("%q: error %q, expected %q", test.source, err, test.msg)
+		}
+	}
+}
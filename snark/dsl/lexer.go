@@ -0,0 +1,183 @@
+package dsl
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNewline
+	tokenIndent
+	tokenDedent
+	tokenIdent
+	tokenNumber
+	tokenKeyword
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  Pos
+}
+
+var keywords = map[string]bool{
+	"def": true, "return": true, "assert": true, "for": true, "in": true, "range": true,
+	"private": true, "public": true, "field": true, "bool": true,
+	"and": true, "or": true, "not": true, "true": true, "false": true,
+}
+
+// operators lists the two character operators before the single ones, so the
+// longest match is found first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "->",
+	"+", "-", "*", "/", "(", ")", ",", ":", "=", "<", ">",
+}
+
+// lex splits the source into tokens. As in Python, a line that is indented
+// more than the previous one starts with an indent token, one that is
+// indented less with a dedent for every block it closes, and lines end with a
+// newline token unless they are inside parentheses. Blank lines and comments
+// are skipped.
+func lex(source string) ([]token, error) {
+
+	var tokens []token
+
+	var indents = []int{0}
+	var depth int
+
+	var number int
+	var line string
+
+	for number, line = range strings.Split(source, "\n") {
+
+		line = strings.TrimRight(line, "\r")
+
+		var comment = strings.IndexByte(line, '#')
+		if comment >= 0 {
+			line = line[:comment]
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var offset int
+		for offset < len(line) && (line[offset] == ' ' || line[offset] == '\t') {
+			offset++
+		}
+
+		var pos = Pos{Line: number + 1, Column: offset + 1}
+
+		if depth == 0 {
+
+			if strings.ContainsRune(line[:offset], '\t') {
+				return nil, errorf(pos, "indentation with tabs")
+			}
+
+			switch {
+			case offset > indents[len(indents)-1]:
+				indents = append(indents, offset)
+				tokens = append(tokens, token{kind: tokenIndent, pos: pos})
+			case offset < indents[len(indents)-1]:
+
+				for offset < indents[len(indents)-1] {
+					indents = indents[:len(indents)-1]
+					tokens = append(tokens, token{kind: tokenDedent, pos: pos})
+				}
+
+				if offset != indents[len(indents)-1] {
+					return nil, errorf(pos, "indentation does not match any outer block")
+				}
+			}
+		}
+
+		for offset < len(line) {
+
+			var c = line[offset]
+			var start = offset
+
+			pos = Pos{Line: number + 1, Column: offset + 1}
+
+			switch {
+			case c == ' ' || c == '\t':
+				offset++
+				continue
+
+			case isLetter(c):
+
+				for offset < len(line) && (isLetter(line[offset]) || isDigit(line[offset])) {
+					offset++
+				}
+
+				var kind = tokenIdent
+				if keywords[line[start:offset]] {
+					kind = tokenKeyword
+				}
+
+				tokens = append(tokens, token{kind: kind, text: line[start:offset], pos: pos})
+				continue
+
+			case isDigit(c):
+
+				for offset < len(line) && isDigit(line[offset]) {
+					offset++
+				}
+
+				tokens = append(tokens, token{kind: tokenNumber, text: line[start:offset], pos: pos})
+				continue
+			}
+
+			var op string
+			for _, op = range operators {
+				if strings.HasPrefix(line[offset:], op) {
+					break
+				}
+			}
+
+			if !strings.HasPrefix(line[offset:], op) {
+				return nil, errorf(pos, "unexpected %q", c)
+			}
+
+			switch op {
+			case "(":
+				depth++
+			case ")":
+				if depth == 0 {
+					return nil, errorf(pos, "unbalanced ')'")
+				}
+				depth--
+			}
+
+			offset += len(op)
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+		}
+
+		if depth == 0 {
+			tokens = append(tokens, token{kind: tokenNewline, pos: Pos{Line: number + 1, Column: len(line) + 1}})
+		}
+	}
+
+	var end = Pos{Line: strings.Count(source, "\n") + 1, Column: 1}
+
+	if depth > 0 {
+		return nil, errorf(end, "unclosed '('")
+	}
+
+	var i int
+	for i = 1; i < len(indents); i++ {
+		tokens = append(tokens, token{kind: tokenDedent, pos: end})
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: end}), nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,513 @@
+package dsl
+
+import (
+	"math/big"
+)
+
+// Parse builds the syntax tree of a program for the grammar
+//
+//	program    = { function }
+//	function   = "def" ident "(" [ param { "," param } ] ")" [ "->" type ] ":" block
+//	param      = [ "private" | "public" ] [ type ] ident
+//	type       = "field" | "bool"
+//	block      = statement | newline indent statement { statement } dedent
+//	statement  = "assert" expr newline
+//	           | "return" expr newline
+//	           | "for" ident "in" "range" "(" expr [ "," expr ] ")" ":" block
+//	           | [ type ] ident "=" expr newline
+//	expr       = and { "or" and }
+//	and        = not { "and" not }
+//	not        = "not" not | comparison
+//	comparison = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum        = product { ( "+" | "-" ) product }
+//	product    = unary { ( "*" | "/" ) unary }
+//	unary      = "-" unary | primary
+//	primary    = number | "true" | "false" | ident [ "(" [ expr { "," expr } ] ")" ] | "(" expr ")"
+//
+// It does not check names or types, which is done by Check.
+func Parse(source string) (*Program, error) {
+
+	var err error
+
+	var p = &parser{}
+	if p.tokens, err = lex(source); err != nil {
+		return nil, err
+	}
+
+	var program = &Program{}
+
+	for p.peek().kind != tokenEOF {
+
+		var function *Function
+		if function, err = p.function(); err != nil {
+			return nil, err
+		}
+
+		program.Functions = append(program.Functions, function)
+	}
+
+	return program, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+
+	var t = p.tokens[p.next]
+
+	if t.kind != tokenEOF {
+		p.next++
+	}
+
+	return t
+}
+
+// is reports whether the next token is the keyword or operator text.
+func (p *parser) is(text string) bool {
+
+	var t = p.peek()
+
+	return (t.kind == tokenKeyword || t.kind == tokenOp) && t.text == text
+}
+
+// accept consumes the next token if it is the keyword or operator text.
+func (p *parser) accept(text string) bool {
+
+	if p.is(text) {
+		p.advance()
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(text string) (token, error) {
+
+	if !p.is(text) {
+		return token{}, p.unexpected("'" + text + "'")
+	}
+
+	return p.advance(), nil
+}
+
+func (p *parser) expectKind(kind tokenKind, what string) (token, error) {
+
+	if p.peek().kind != kind {
+		return token{}, p.unexpected(what)
+	}
+
+	return p.advance(), nil
+}
+
+func (p *parser) unexpected(expected string) error {
+
+	var t = p.peek()
+
+	var found = "'" + t.text + "'"
+
+	switch t.kind {
+	case tokenEOF:
+		found = "end of file"
+	case tokenNewline:
+		found = "end of line"
+	case tokenIndent:
+		found = "indentation"
+	case tokenDedent:
+		found = "end of block"
+	}
+
+	return errorf(t.pos, "expected %s, found %s", expected, found)
+}
+
+func (p *parser) function() (*Function, error) {
+
+	var err error
+
+	var def token
+	if def, err = p.expect("def"); err != nil {
+		return nil, err
+	}
+
+	var name token
+	if name, err = p.expectKind(tokenIdent, "function name"); err != nil {
+		return nil, err
+	}
+
+	var function = &Function{Pos: def.pos, Name: name.text}
+
+	if _, err = p.expect("("); err != nil {
+		return nil, err
+	}
+
+	for !p.is(")") {
+
+		if len(function.Params) > 0 {
+			if _, err = p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		var param = &Param{Pos: p.peek().pos, Type: Field}
+
+		switch {
+		case p.accept("private"):
+			param.Visibility = Private
+		case p.accept("public"):
+			param.Visibility = Public
+		}
+
+		if p.is("field") || p.is("bool") {
+			param.Type = p.typ()
+		}
+
+		var ident token
+		if ident, err = p.expectKind(tokenIdent, "parameter name"); err != nil {
+			return nil, err
+		}
+
+		param.Name = ident.text
+		function.Params = append(function.Params, param)
+	}
+
+	p.advance()
+
+	if p.accept("->") {
+
+		if !p.is("field") && !p.is("bool") {
+			return nil, p.unexpected("result type")
+		}
+
+		function.Result = p.typ()
+	}
+
+	if _, err = p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	if function.Body, err = p.block(); err != nil {
+		return nil, err
+	}
+
+	return function, nil
+}
+
+func (p *parser) typ() Type {
+
+	if p.advance().text == "bool" {
+		return Bool
+	}
+
+	return Field
+}
+
+func (p *parser) block() ([]Stmt, error) {
+
+	var err error
+
+	if p.peek().kind != tokenNewline {
+
+		var statement Stmt
+		if statement, err = p.statement(); err != nil {
+			return nil, err
+		}
+
+		return []Stmt{statement}, nil
+	}
+
+	p.advance()
+
+	if _, err = p.expectKind(tokenIndent, "indented block"); err != nil {
+		return nil, err
+	}
+
+	var statements []Stmt
+
+	for p.peek().kind != tokenDedent {
+
+		var statement Stmt
+		if statement, err = p.statement(); err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, statement)
+	}
+
+	p.advance()
+
+	return statements, nil
+}
+
+func (p *parser) statement() (Stmt, error) {
+
+	var err error
+
+	var start = p.peek()
+
+	var statement Stmt
+
+	switch {
+	case p.accept("assert"):
+
+		var cond Expr
+		if cond, err = p.expr(); err != nil {
+			return nil, err
+		}
+
+		statement = &AssertStmt{Pos: start.pos, Cond: cond}
+
+	case p.accept("return"):
+
+		var value Expr
+		if value, err = p.expr(); err != nil {
+			return nil, err
+		}
+
+		statement = &ReturnStmt{Pos: start.pos, Value: value}
+
+	case p.accept("for"):
+		return p.loop(start)
+
+	default:
+
+		var assign = &AssignStmt{Pos: start.pos}
+
+		if p.is("field") || p.is("bool") {
+			assign.Type = p.typ()
+		}
+
+		var name token
+		if name, err = p.expectKind(tokenIdent, "statement"); err != nil {
+			return nil, err
+		}
+
+		assign.Name = name.text
+
+		if _, err = p.expect("="); err != nil {
+			return nil, err
+		}
+
+		if assign.Value, err = p.expr(); err != nil {
+			return nil, err
+		}
+
+		statement = assign
+	}
+
+	if _, err = p.expectKind(tokenNewline, "end of line"); err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+func (p *parser) loop(start token) (Stmt, error) {
+
+	var err error
+
+	var name token
+	if name, err = p.expectKind(tokenIdent, "loop variable"); err != nil {
+		return nil, err
+	}
+
+	var loop = &ForStmt{Pos: start.pos, Var: name.text}
+
+	var text string
+	for _, text = range []string{"in", "range", "("} {
+		if _, err = p.expect(text); err != nil {
+			return nil, err
+		}
+	}
+
+	var first Expr
+	if first, err = p.expr(); err != nil {
+		return nil, err
+	}
+
+	loop.From, loop.To = &Number{Pos: first.pos(), Value: new(big.Int)}, first
+
+	if p.accept(",") {
+
+		loop.From = first
+
+		if loop.To, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, text = range []string{")", ":"} {
+		if _, err = p.expect(text); err != nil {
+			return nil, err
+		}
+	}
+
+	if loop.Body, err = p.block(); err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (p *parser) expr() (Expr, error) {
+	return p.binary(0)
+}
+
+// levels lists the binary operators from the lowest precedence to the
+// highest. Comparisons do not associate, so a < b < c is an error.
+var levels = [][]string{
+	{"or"},
+	{"and"},
+	nil, // "not"
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+// comparisons is the level of the comparison operators.
+const comparisons = 3
+
+func (p *parser) binary(level int) (Expr, error) {
+
+	var err error
+
+	switch {
+	case level == len(levels):
+		return p.unary()
+	case levels[level] == nil:
+
+		var start = p.peek()
+
+		if p.accept("not") {
+
+			var x Expr
+			if x, err = p.binary(level); err != nil {
+				return nil, err
+			}
+
+			return &Unary{Pos: start.pos, Op: "not", X: x}, nil
+		}
+
+		return p.binary(level + 1)
+	}
+
+	var left Expr
+	if left, err = p.binary(level + 1); err != nil {
+		return nil, err
+	}
+
+	for {
+
+		var op string
+		for _, op = range levels[level] {
+			if p.is(op) {
+				break
+			}
+		}
+
+		if !p.is(op) {
+			return left, nil
+		}
+
+		var t = p.advance()
+
+		var right Expr
+		if right, err = p.binary(level + 1); err != nil {
+			return nil, err
+		}
+
+		left = &Binary{Pos: t.pos, Op: op, Left: left, Right: right}
+
+		if level != comparisons {
+			continue
+		}
+
+		for _, op = range levels[level] {
+			if p.is(op) {
+				return nil, errorf(p.peek().pos, "comparisons can not be chained")
+			}
+		}
+
+		return left, nil
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+
+	var err error
+
+	var t = p.peek()
+
+	if p.accept("-") {
+
+		var x Expr
+		if x, err = p.unary(); err != nil {
+			return nil, err
+		}
+
+		return &Unary{Pos: t.pos, Op: "-", X: x}, nil
+	}
+
+	switch {
+	case t.kind == tokenNumber:
+
+		p.advance()
+
+		var value, _ = new(big.Int).SetString(t.text, 10)
+
+		return &Number{Pos: t.pos, Value: value}, nil
+
+	case p.accept("true"), p.accept("false"):
+		return &Boolean{Pos: t.pos, Value: t.text == "true"}, nil
+
+	case p.accept("("):
+
+		var x Expr
+		if x, err = p.expr(); err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect(")"); err != nil {
+			return nil, err
+		}
+
+		return x, nil
+
+	case t.kind == tokenIdent:
+
+		p.advance()
+
+		if !p.accept("(") {
+			return &Name{Pos: t.pos, Name: t.text}, nil
+		}
+
+		var call = &Call{Pos: t.pos, Name: t.text}
+
+		for !p.is(")") {
+
+			if len(call.Args) > 0 {
+				if _, err = p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+
+			var arg Expr
+			if arg, err = p.expr(); err != nil {
+				return nil, err
+			}
+
+			call.Args = append(call.Args, arg)
+		}
+
+		p.advance()
+
+		return call, nil
+	}
+
+	return nil, p.unexpected("expression")
+}
@@ -0,0 +1,298 @@
+package snark
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// ASCII draws the tree with one node per line, each operand below its
+// operation and indented with box drawing characters as in the output of
+// tree(1). Variables and multiplication gates are labelled with the index of
+// their wire, e.g. "* [2] = 24" for x * (x + 5) on wire 2 at x = 3. The
+// values are only shown if a witness of the circuit of Flatten is given, and
+// are read from it for the wires and computed for additions and subtractions.
+func (t *BinaryTree) ASCII(witness []*field.Element) string {
+
+	var b strings.Builder
+
+	t.ascii(&b, "", "", witness)
+
+	return b.String()
+}
+
+func (t *BinaryTree) ascii(b *strings.Builder, first, rest string, witness []*field.Element) {
+
+	fmt.Fprintf(b, "%s%s\n", first, t.label(witness, " "))
+
+	if t.IsLeaf() {
+		return
+	}
+
+	t.left.ascii(b, rest+"├── ", rest+"│   ", witness)
+	t.right.ascii(b, rest+"└── ", rest+"    ", witness)
+}
+
+// DOT writes the tree as a Graphviz digraph with an edge from every operand
+// to its operation, labelled as for ASCII. A variable has a single node, so
+// the graph shows where its wire fans out.
+func (t *BinaryTree) DOT(witness []*field.Element) string {
+
+	var b strings.Builder
+
+	b.WriteString("digraph circuit {\n")
+	b.WriteString("\trankdir=BT;\n")
+
+	var ids = make(map[*BinaryTree]string)
+	var declared = make(map[string]bool)
+
+	t.walk(func(node *BinaryTree) {
+
+		var id = fmt.Sprintf("n%d", len(declared))
+		if node.IsLeaf() && node.value == nil {
+			id = fmt.Sprintf("w%d", node.wire)
+		}
+
+		ids[node] = id
+
+		if declared[id] {
+			return
+		}
+
+		declared[id] = true
+
+		var shape = "circle"
+		if node.IsLeaf() {
+			shape = "box"
+		}
+
+		fmt.Fprintf(&b, "\t%s [shape=%s, label=%q];\n", id, shape, node.label(witness, "\n"))
+	})
+
+	t.walk(func(node *BinaryTree) {
+		if !node.IsLeaf() {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", ids[node.left], ids[node])
+			fmt.Fprintf(&b, "\t%s -> %s;\n", ids[node.right], ids[node])
+		}
+	})
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// label names the node, its wire if it has one and its value if there is a
+// witness, separated by sep.
+func (t *BinaryTree) label(witness []*field.Element, sep string) string {
+
+	var label = t.String()
+	if !t.IsLeaf() {
+		label = t.op
+	}
+
+	if t.wire > 0 {
+		label += fmt.Sprintf(" [%d]", t.wire)
+	}
+
+	if witness != nil && t.value == nil {
+		label += fmt.Sprintf("%s= %s", sep, t.evaluate(witness))
+	}
+
+	return label
+}
+
+// evaluate returns the value of the node for the witness of its circuit.
+func (t *BinaryTree) evaluate(witness []*field.Element) *field.Element {
+
+	var f = witness[0].Field()
+
+	switch {
+	case t.value != nil:
+		return f.NewElement(t.value)
+	case t.wire > 0:
+		return witness[t.wire]
+	}
+
+	var left, right = t.left.evaluate(witness), t.right.evaluate(witness)
+
+	switch t.op {
+	case "+":
+		return new(field.Element).Add(left, right)
+	case "-":
+		return new(field.Element).Sub(left, right)
+	}
+
+	return new(field.Element).Mul(left, right)
+}
+
+// ASCII lists the gates of the circuit as equations between named wires,
+// followed by the constraints the gates did not add, such as divisions,
+// assertions and outputs. For x * x * x + x + 5 flattened and solved for
+// x = 3 it is
+//
+//	w1 = (x) * (x) = 9
+//	w2 = (w1) * (x) = 27
+//	(1) * (w2 + x + 5) = out = 35
+//
+// where the values of the right hand sides are only shown if a witness is
+// given.
+func (c *Circuit) ASCII(witness []*field.Element) string {
+
+	var b strings.Builder
+
+	var gates = make(map[int]bool)
+
+	var gate Gate
+	for _, gate = range c.Gates {
+
+		gates[gate.Constraint] = true
+
+		fmt.Fprintf(&b, "%s = (%s) * (%s)", c.r1cs.Variables[gate.Output].Name, c.format(gate.Left), c.format(gate.Right))
+
+		if witness != nil {
+			fmt.Fprintf(&b, " = %s", witness[gate.Output])
+		}
+
+		b.WriteString("\n")
+	}
+
+	var j int
+	var constraint qap.Constraint
+
+	for j, constraint = range c.r1cs.Constraints {
+
+		if gates[j] {
+			continue
+		}
+
+		fmt.Fprintf(&b, "(%s) * (%s) = %s", c.format(constraint.A), c.format(constraint.B), c.format(constraint.C))
+
+		if witness != nil {
+			fmt.Fprintf(&b, " = %s", constraint.C.Evaluate(c.Field, witness))
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// DOT writes the gates of the circuit as a Graphviz digraph. Every wire used
+// by a gate is a box and every gate a circle, with edges from the wires of its
+// operands, labelled with their coefficients when they are not 1, and an edge
+// to its output wire. The values of the wires are added if a witness is given.
+func (c *Circuit) DOT(witness []*field.Element) string {
+
+	var b strings.Builder
+
+	b.WriteString("digraph circuit {\n")
+	b.WriteString("\trankdir=BT;\n")
+
+	var declared = make(map[int]bool)
+
+	var declare = func(index int) {
+
+		if declared[index] {
+			return
+		}
+
+		declared[index] = true
+
+		var label = c.r1cs.Variables[index].Name
+		if witness != nil {
+			label += fmt.Sprintf("\n= %s", witness[index])
+		}
+
+		fmt.Fprintf(&b, "\tw%d [shape=box, label=%q];\n", index, label)
+	}
+
+	var i int
+	var gate Gate
+
+	for i, gate = range c.Gates {
+
+		fmt.Fprintf(&b, "\tg%d [shape=circle, label=\"*\"];\n", i)
+
+		var operand qap.LinearCombination
+		for _, operand = range []qap.LinearCombination{gate.Left, gate.Right} {
+
+			var term qap.Term
+			for _, term = range operand {
+
+				declare(term.Index)
+
+				if term.Coeff.IsOne() {
+					fmt.Fprintf(&b, "\tw%d -> g%d;\n", term.Index, i)
+					continue
+				}
+
+				fmt.Fprintf(&b, "\tw%d -> g%d [label=%q];\n", term.Index, i, signed(term.Coeff))
+			}
+		}
+
+		declare(gate.Output)
+
+		fmt.Fprintf(&b, "\tg%d -> w%d;\n", i, gate.Output)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// format writes a linear combination with the names of its wires, showing the
+// coefficients that are not 1 and the constant wire by its value.
+func (c *Circuit) format(lc qap.LinearCombination) string {
+
+	if len(lc) == 0 {
+		return "0"
+	}
+
+	var b strings.Builder
+
+	var i int
+	var term qap.Term
+
+	for i, term = range lc {
+
+		var coeff = signed(term.Coeff)
+		var negative = strings.HasPrefix(coeff, "-")
+
+		switch {
+		case i > 0 && negative:
+			b.WriteString(" - ")
+			coeff = coeff[1:]
+		case i > 0:
+			b.WriteString(" + ")
+		case negative:
+			b.WriteString("-")
+			coeff = coeff[1:]
+		}
+
+		switch {
+		case term.Index == 0:
+			b.WriteString(coeff)
+		case coeff == "1":
+			b.WriteString(c.r1cs.Variables[term.Index].Name)
+		default:
+			fmt.Fprintf(&b, "%s * %s", coeff, c.r1cs.Variables[term.Index].Name)
+		}
+	}
+
+	return b.String()
+}
+
+// signed writes x as -(p - x) when that is shorter, so small negative
+// coefficients are readable.
+func signed(x *field.Element) string {
+
+	var negated = new(field.Element).Neg(x)
+
+	if negated.Big().BitLen() < x.Big().BitLen() {
+		return "-" + negated.String()
+	}
+
+	return x.String()
+}
@@ -0,0 +1,136 @@
+package snark
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestExport(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var tree *BinaryTree
+	if tree, err = Parse("x * x * x + x + 5"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var c = Flatten(f, tree)
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	var expected = strings.Join([]string{
+		"+ = 35",
+		"├── + = 30",
+		"│   ├── * [3] = 27",
+		"│   │   ├── * [2] = 9",
+		"│   │   │   ├── x [1] = 3",
+		"│   │   │   └── x [1] = 3",
+		"│   │   └── x [1] = 3",
+		"│   └── x [1] = 3",
+		"└── 5",
+		"",
+	}, "\n")
+
+	if tree.ASCII(witness) != expected {
+		t.Errorf("tree is drawn as\n%s\nexpected\n%s", tree.ASCII(witness), expected)
+	}
+
+	if strings.Contains(tree.ASCII(nil), "=") {
+		t.Errorf("expected no values without a witness:\n%s", tree.ASCII(nil))
+	}
+
+	expected = strings.Join([]string{
+		"w1 = (x) * (x) = 9",
+		"w2 = (w1) * (x) = 27",
+		"(1) * (w2 + x + 5) = out = 35",
+		"",
+	}, "\n")
+
+	if c.ASCII(witness) != expected {
+		t.Errorf("circuit is drawn as\n%s\nexpected\n%s", c.ASCII(witness), expected)
+	}
+
+	var dot = tree.DOT(witness)
+
+	// x has a single node which fans out to three operations.
+	if strings.Count(dot, "w1 [") != 1 || strings.Count(dot, "w1 -> ") != 4 {
+		t.Errorf("expected a single node for x with four edges:\n%s", dot)
+	}
+
+	var line string
+	for _, line = range []string{"digraph circuit {", `n2 [shape=circle, label="* [3]\n= 27"];`, "n3 -> n4;"} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected %q in\n%s", line, dot)
+		}
+	}
+
+	dot = c.DOT(witness)
+
+	for _, line = range []string{`w3 [shape=box, label="w2\n= 27"];`, "w2 -> g1;", "w1 -> g1;", "g1 -> w3;"} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected %q in\n%s", line, dot)
+		}
+	}
+}
+
+func TestExportCoefficients(t *testing.T) {
+
+	var err error
+
+	var tree *BinaryTree
+	if tree, err = Parse("x1 - 2 * x2 * x1"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var c = Flatten(field.New(bn256.Order), tree)
+
+	var expected = "w1 = (2 * x2) * (x1)\n(1) * (x1 - w1) = out\n"
+
+	if c.ASCII(nil) != expected {
+		t.Errorf("circuit is drawn as\n%s\nexpected\n%s", c.ASCII(nil), expected)
+	}
+
+	if !strings.Contains(c.DOT(nil), `w2 -> g0 [label="2"];`) {
+		t.Errorf("expected the coefficient of x2 on its edge:\n%s", c.DOT(nil))
+	}
+}
+
+func TestExportDivision(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x, y = c.PrivateInput("x"), c.PrivateInput("y")
+
+	// The check x * w2 = w1 of the division has a gate wire on the right, but
+	// is not the constraint of the gate.
+	var p = c.Mul(x, y)
+	c.Output("out", c.Div(p, x))
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "y": f.NewInt64(2)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	var expected = strings.Join([]string{
+		"w1 = (x) * (y) = 6",
+		"(x) * (w2) = w1 = 6",
+		"(1) * (w2) = out = 2",
+		"",
+	}, "\n")
+
+	if len(c.R1CS().Constraints) != 3 || c.ASCII(witness) != expected {
+		t.Errorf("circuit is drawn as\n%s\nexpected\n%s", c.ASCII(witness), expected)
+	}
+}
@@ -0,0 +1,56 @@
+package snark
+
+import (
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// Flatten builds the circuit of an expression over the field. The variables
+// of the tree become private inputs and its value the public output "out".
+// The multiplications of two non-constant operands become gates, in the order
+// they are evaluated, and their nodes are given the output wire of the gate.
+func Flatten(f *field.Field, tree *BinaryTree) *Circuit {
+
+	var c = NewCircuit(f)
+
+	var name string
+	for _, name = range tree.Inputs() {
+		c.PrivateInput(name)
+	}
+
+	c.Output("out", c.flatten(tree))
+
+	return c
+}
+
+// flatten returns the variable equal to the value of the node, adding gates
+// for the multiplications below it.
+func (c *Circuit) flatten(node *BinaryTree) Variable {
+
+	if node.IsLeaf() {
+
+		if node.value != nil {
+			return c.ConstantElement(c.Field.NewElement(node.value))
+		}
+
+		return c.wire(node.wire)
+	}
+
+	var left = c.flatten(node.left)
+	var right = c.flatten(node.right)
+
+	switch node.op {
+	case "+":
+		return c.Add(left, right)
+	case "-":
+		return c.Sub(left, right)
+	}
+
+	var gates = len(c.Gates)
+	var product = c.Mul(left, right)
+
+	if len(c.Gates) > gates {
+		node.wire = c.Gates[gates].Output
+	}
+
+	return product
+}
@@ -0,0 +1,104 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+func TestFlatten(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// The expression of example 4 in the qap package.
+	var tree *BinaryTree
+	if tree, err = Parse("x1 * (x2 - 2 * x3) * (x5 + 7 * x6)"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var c = Flatten(f, tree)
+
+	if len(c.Gates) != 2 {
+		t.Fatalf("gates = %d, expected 2", len(c.Gates))
+	}
+
+	if tree.Left().Wire() != 6 || tree.Wire() != 7 {
+		t.Errorf("gate wires = %d, %d, expected 6, 7", tree.Left().Wire(), tree.Wire())
+	}
+
+	var r1cs = c.R1CS()
+
+	if len(r1cs.Constraints) != 2 {
+		t.Errorf("constraints = %d, expected 2", len(r1cs.Constraints))
+	}
+
+	// a1 = 4, a2 = 7, a3 = 3, a5 = 2, a6 = 1, a4 = 4, a7 = 36
+	var witness = []*field.Element{
+		f.NewInt64(1), f.NewInt64(4), f.NewInt64(7), f.NewInt64(3), f.NewInt64(2), f.NewInt64(1),
+		f.NewInt64(4), f.NewInt64(36),
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness of example 4 to satisfy the circuit: %v", err)
+	}
+
+	var public = r1cs.Public()
+	if len(public) != 2 || public[1] != 7 {
+		t.Errorf("public = %v, expected [0 7]", public)
+	}
+
+	// The output is the wire of the last gate, which is renamed rather than
+	// copied.
+	var index int
+	if index, _ = r1cs.Variable("out"); index != 7 {
+		t.Errorf("out = %d, expected the wire 7 of the last gate", index)
+	}
+
+	witness[7] = f.NewInt64(35)
+
+	var ok bool
+	if _, ok = r1cs.IsSatisfied(witness).(*qap.UnsatisfiedError); !ok {
+		t.Errorf("expected a wrong output not to satisfy the circuit")
+	}
+}
+
+func TestFlattenLinear(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// The constants are folded and x - x cancels, which leaves one gate.
+	var tree *BinaryTree
+	if tree, err = Parse("2 * 3 * x * y + x - x + 5"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var c = Flatten(f, tree)
+
+	if len(c.Gates) != 1 {
+		t.Fatalf("gates = %d, expected 1", len(c.Gates))
+	}
+
+	var r1cs = c.R1CS()
+
+	// x = 2, y = 3, w1 = 36 = 6 * x * y, out = 41
+	var witness = []*field.Element{
+		f.NewInt64(1), f.NewInt64(2), f.NewInt64(3), f.NewInt64(36), f.NewInt64(41),
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness to satisfy the circuit: %v", err)
+	}
+
+	var index int
+	var ok bool
+
+	if index, ok = r1cs.Variable("out"); !ok || !r1cs.Variables[index].Public {
+		t.Errorf("expected a public output variable")
+	}
+}
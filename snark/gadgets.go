@@ -0,0 +1,181 @@
+package snark
+
+import (
+	"math/big"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// AssertBoolean adds the constraint a * (a - 1) = 0, so a is either 0 or 1.
+func (c *Circuit) AssertBoolean(a Variable) {
+
+	if a.IsConstant() && (c.constant(a).IsZero() || c.constant(a).IsOne()) {
+		return
+	}
+
+	c.r1cs.AddConstraint(a.lc, c.Sub(a, c.One()).lc, nil)
+}
+
+// ToBinary decomposes a into n bits, least significant first, with n
+// constraints for the bits and one for their sum. It can only be satisfied if
+// a is less than 2^n. It panics if 2^n is not less than the modulus, since the
+// sum of the bits could then wrap around to a with other bits.
+func (c *Circuit) ToBinary(a Variable, n int) []Variable {
+
+	if n >= c.Field.Modulus().BitLen() {
+		panic("snark: 2^n must be less than the modulus to decompose into n bits")
+	}
+
+	var bits = make([]Variable, n)
+
+	// Solve runs the hints in order, so a is evaluated once for the first
+	// bit and reused for the others.
+	var value *big.Int
+
+	var i int
+	for i = range bits {
+
+		var bit = i
+
+		bits[i] = c.Compute(func(values []*field.Element) (*field.Element, error) {
+
+			if bit == 0 {
+				value = a.lc.Evaluate(c.Field, values).Big()
+			}
+
+			return c.Field.NewInt64(int64(value.Bit(bit))), nil
+		})
+
+		c.AssertBoolean(bits[i])
+	}
+
+	c.AssertEqual(c.FromBinary(bits), a)
+
+	return bits
+}
+
+// FromBinary returns the sum of bits[i] * 2^i. It does not add constraints,
+// and in particular does not check the bits are boolean.
+func (c *Circuit) FromBinary(bits []Variable) Variable {
+
+	var terms = make([]Variable, len(bits))
+
+	var power = c.Field.One()
+	var two = c.Field.NewInt64(2)
+
+	var i int
+	for i = range bits {
+		terms[i] = c.Scale(bits[i], power)
+		power.Mul(power, two)
+	}
+
+	return c.Add(terms...)
+}
+
+// IsZero returns 1 if a is zero and 0 otherwise, with two constraints: for
+// the hinted inverse m of a (or 0), a * m = 1 - out and a * out = 0.
+func (c *Circuit) IsZero(a Variable) Variable {
+
+	if a.IsConstant() {
+
+		if c.constant(a).IsZero() {
+			return c.One()
+		}
+
+		return c.Constant(0)
+	}
+
+	var inverse = c.Compute(func(values []*field.Element) (*field.Element, error) {
+
+		var value = a.lc.Evaluate(c.Field, values)
+		if value.IsZero() {
+			return value, nil
+		}
+
+		return value.Inv(value), nil
+	})
+
+	var out = c.Compute(func(values []*field.Element) (*field.Element, error) {
+
+		if a.lc.Evaluate(c.Field, values).IsZero() {
+			return c.Field.One(), nil
+		}
+
+		return c.Field.Zero(), nil
+	})
+
+	c.r1cs.AddConstraint(a.lc, inverse.lc, c.Sub(c.One(), out).lc)
+	c.r1cs.AddConstraint(a.lc, out.lc, nil)
+
+	return out
+}
+
+// IsEqual returns 1 if a = b and 0 otherwise.
+func (c *Circuit) IsEqual(a, b Variable) Variable {
+	return c.IsZero(c.Sub(a, b))
+}
+
+// LessThan returns 1 if a < b and 0 otherwise, for a and b less than 2^n. The
+// difference a - b + 2^n is decomposed into n + 1 bits, and its top bit is 0
+// exactly when a < b. It panics if 2^(n+1) is not less than the modulus.
+func (c *Circuit) LessThan(a, b Variable, n int) Variable {
+
+	var offset = c.Field.NewElement(new(big.Int).Lsh(big.NewInt(1), uint(n)))
+
+	var bits = c.ToBinary(c.Add(c.Sub(a, b), c.ConstantElement(offset)), n+1)
+
+	return c.Not(bits[n])
+}
+
+// Select returns a if cond is 1 and b if it is 0, with one constraint for
+// b + cond * (a - b). The condition is assumed to be boolean.
+func (c *Circuit) Select(cond, a, b Variable) Variable {
+	return c.Add(b, c.Mul(cond, c.Sub(a, b)))
+}
+
+// Mux returns options[i] where the bits of the index i are given least
+// significant first, with one Select per pair of options. It panics unless
+// there are exactly 2^len(bits) options.
+func (c *Circuit) Mux(bits []Variable, options []Variable) Variable {
+
+	if len(options) != 1<<uint(len(bits)) {
+		panic("snark: mux needs 2^len(bits) options")
+	}
+
+	var layer = options
+
+	var bit Variable
+	for _, bit = range bits {
+
+		var next = make([]Variable, len(layer)/2)
+
+		var i int
+		for i = range next {
+			next[i] = c.Select(bit, layer[2*i+1], layer[2*i])
+		}
+
+		layer = next
+	}
+
+	return layer[0]
+}
+
+// Not returns 1 - a for a boolean a.
+func (c *Circuit) Not(a Variable) Variable {
+	return c.Sub(c.One(), a)
+}
+
+// And returns a * b for booleans a and b.
+func (c *Circuit) And(a, b Variable) Variable {
+	return c.Mul(a, b)
+}
+
+// Or returns a + b - a * b for booleans a and b.
+func (c *Circuit) Or(a, b Variable) Variable {
+	return c.Sub(c.Add(a, b), c.Mul(a, b))
+}
+
+// Xor returns a + b - 2 * a * b for booleans a and b.
+func (c *Circuit) Xor(a, b Variable) Variable {
+	return c.Sub(c.Add(a, b), c.Scale(c.Mul(a, b), c.Field.NewInt64(2)))
+}
@@ -0,0 +1,282 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// solve assigns small values to the inputs and returns the witness, or nil if
+// the constraints are not satisfied.
+func solve(t *testing.T, c *Circuit, inputs map[string]int64) []*field.Element {
+
+	var err error
+
+	var values = make(map[string]*field.Element)
+
+	var name string
+	for name = range inputs {
+		values[name] = c.Field.NewInt64(inputs[name])
+	}
+
+	var witness []*field.Element
+	if witness, err = c.Solve(values); err != nil {
+		return nil
+	}
+
+	return witness
+}
+
+// index returns the wire of a variable that is a single wire.
+func index(v Variable) int {
+	return v.LinearCombination()[0].Index
+}
+
+func TestAssertBoolean(t *testing.T) {
+
+	var c = NewCircuit(field.New(bn256.Order))
+
+	c.AssertBoolean(c.PrivateInput("b"))
+	c.AssertBoolean(c.One())
+
+	if len(c.R1CS().Constraints) != 1 {
+		t.Errorf("constraints = %d, expected 1", len(c.R1CS().Constraints))
+	}
+
+	var b int64
+	for b = -1; b <= 2; b++ {
+		if (solve(t, c, map[string]int64{"b": b}) != nil) != (b == 0 || b == 1) {
+			t.Errorf("boolean check of %d failed", b)
+		}
+	}
+}
+
+func TestBinary(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var a = c.PrivateInput("a")
+	var bits = c.ToBinary(a, 8)
+
+	c.Output("out", c.FromBinary(bits[4:]))
+
+	// 8 boolean constraints, the sum, and the output.
+	if len(c.R1CS().Constraints) != 10 {
+		t.Errorf("constraints = %d, expected 10", len(c.R1CS().Constraints))
+	}
+
+	var witness = solve(t, c, map[string]int64{"a": 0xa7})
+	if witness == nil {
+		t.Fatalf("expected 0xa7 to fit in 8 bits")
+	}
+
+	var expected = []int64{1, 1, 1, 0, 0, 1, 0, 1}
+
+	var i int
+	for i = range expected {
+		if !witness[index(bits[i])].Equal(f.NewInt64(expected[i])) {
+			t.Errorf("bit %d = %d, expected %d", i, witness[index(bits[i])], expected[i])
+		}
+	}
+
+	if !witness[len(witness)-1].Equal(f.NewInt64(0xa)) {
+		t.Errorf("high nibble = %d, expected 10", witness[len(witness)-1])
+	}
+
+	if solve(t, c, map[string]int64{"a": 256}) != nil {
+		t.Errorf("expected 256 not to fit in 8 bits")
+	}
+
+	// Swapping two bits keeps them boolean but breaks the sum.
+	witness[index(bits[3])], witness[index(bits[5])] = witness[index(bits[5])], witness[index(bits[3])]
+
+	if c.R1CS().IsSatisfied(witness) == nil {
+		t.Errorf("expected wrong bits not to satisfy the decomposition")
+	}
+}
+
+func TestIsZero(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var a = c.PrivateInput("a")
+	var b = c.PrivateInput("b")
+
+	var equal = c.IsEqual(a, b)
+
+	if len(c.R1CS().Constraints) != 2 {
+		t.Errorf("constraints = %d, expected 2", len(c.R1CS().Constraints))
+	}
+
+	var cases = []struct {
+		a, b, expected int64
+	}{
+		{5, 5, 1},
+		{5, 6, 0},
+		{0, -3, 0},
+	}
+
+	var d = cases[0]
+	for _, d = range cases {
+
+		var witness = solve(t, c, map[string]int64{"a": d.a, "b": d.b})
+		if witness == nil {
+			t.Fatalf("IsEqual(%d, %d) is not satisfiable", d.a, d.b)
+		}
+
+		if !witness[index(equal)].Equal(f.NewInt64(d.expected)) {
+			t.Errorf("IsEqual(%d, %d) = %d, expected %d", d.a, d.b, witness[index(equal)], d.expected)
+		}
+
+		// A prover claiming the opposite result is caught.
+		witness[index(equal)] = f.NewInt64(1 - d.expected)
+
+		if c.R1CS().IsSatisfied(witness) == nil {
+			t.Errorf("IsEqual(%d, %d) = %d should not be satisfied", d.a, d.b, 1-d.expected)
+		}
+	}
+
+	if !c.IsZero(c.Constant(0)).IsConstant() || len(c.R1CS().Constraints) != 2 {
+		t.Errorf("expected IsZero of a constant to be folded")
+	}
+}
+
+func TestLessThan(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var lt = c.LessThan(c.PrivateInput("a"), c.PrivateInput("b"), 8)
+
+	// 9 boolean constraints and the sum.
+	if len(c.R1CS().Constraints) != 10 {
+		t.Errorf("constraints = %d, expected 10", len(c.R1CS().Constraints))
+	}
+
+	var cases = []struct {
+		a, b, expected int64
+	}{
+		{3, 200, 1},
+		{200, 3, 0},
+		{17, 17, 0},
+		{0, 255, 1},
+		{255, 0, 0},
+	}
+
+	var d = cases[0]
+	for _, d = range cases {
+
+		var witness = solve(t, c, map[string]int64{"a": d.a, "b": d.b})
+		if witness == nil {
+			t.Fatalf("LessThan(%d, %d) is not satisfiable", d.a, d.b)
+		}
+
+		// lt = 1 - top bit, so evaluate the combination.
+		var actual = lt.LinearCombination().Evaluate(f, witness)
+
+		if !actual.Equal(f.NewInt64(d.expected)) {
+			t.Errorf("LessThan(%d, %d) = %d, expected %d", d.a, d.b, actual, d.expected)
+		}
+	}
+}
+
+func TestBinaryBound(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var a = c.PrivateInput("a")
+
+	// The modulus has 254 bits, so 2^253 is the largest power of two below it.
+	var bits = f.Modulus().BitLen()
+
+	var cases = []struct {
+		name   string
+		build  func()
+		panics bool
+	}{
+		{"ToBinary(n - 1)", func() { c.ToBinary(a, bits-1) }, false},
+		{"ToBinary(n)", func() { c.ToBinary(a, bits) }, true},
+		{"LessThan(n - 2)", func() { c.LessThan(a, a, bits-2) }, false},
+		{"LessThan(n - 1)", func() { c.LessThan(a, a, bits-1) }, true},
+		{"Mux", func() { c.Mux([]Variable{a}, []Variable{a}) }, true},
+	}
+
+	var d = cases[0]
+	for _, d = range cases {
+		if panicked(d.build) != d.panics {
+			t.Errorf("%s: panicked = %t, expected %t", d.name, !d.panics, d.panics)
+		}
+	}
+}
+
+// panicked reports whether build panics.
+func panicked(build func()) (panicked bool) {
+
+	defer func() {
+		panicked = recover() != nil
+	}()
+
+	build()
+
+	return false
+}
+
+func TestSelectAndLogic(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var a = c.PrivateInput("a")
+	var b = c.PrivateInput("b")
+
+	c.AssertBoolean(a)
+	c.AssertBoolean(b)
+
+	var outputs = []Variable{
+		c.Output("and", c.And(a, b)),
+		c.Output("or", c.Or(a, b)),
+		c.Output("xor", c.Xor(a, b)),
+		c.Output("not", c.Not(a)),
+		c.Output("select", c.Select(a, c.Constant(10), c.Constant(20))),
+		c.Output("mux", c.Mux([]Variable{a, b}, []Variable{c.Constant(30), c.Constant(31), c.Constant(32), c.Constant(33)})),
+	}
+
+	var cases = []struct {
+		a, b     int64
+		expected []int64
+	}{
+		{0, 0, []int64{0, 0, 0, 1, 20, 30}},
+		{0, 1, []int64{0, 1, 1, 1, 20, 32}},
+		{1, 0, []int64{0, 1, 1, 0, 10, 31}},
+		{1, 1, []int64{1, 1, 0, 0, 10, 33}},
+	}
+
+	var d = cases[0]
+	for _, d = range cases {
+
+		var witness = solve(t, c, map[string]int64{"a": d.a, "b": d.b})
+		if witness == nil {
+			t.Fatalf("logic on %d, %d is not satisfiable", d.a, d.b)
+		}
+
+		var i int
+		for i = range outputs {
+			if !witness[index(outputs[i])].Equal(f.NewInt64(d.expected[i])) {
+				t.Errorf("output %d on %d, %d = %d, expected %d", i, d.a, d.b, witness[index(outputs[i])], d.expected[i])
+			}
+		}
+	}
+
+	if solve(t, c, map[string]int64{"a": 2, "b": 0}) != nil {
+		t.Errorf("expected a non-boolean input not to be satisfiable")
+	}
+}
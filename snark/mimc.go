@@ -0,0 +1,124 @@
+package snark
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// MiMCRounds is the number of rounds of the MiMC permutation x -> x^5, which
+// is ceil(log_5(p)) for the 256 bit order of the bn256 groups. The exponent 5
+// is the smallest one coprime to p - 1, so x^5 is a permutation of the field.
+const MiMCRounds = 110
+
+// MiMC encrypts x under key with the MiMC block cipher (eprint 2016/492):
+// x_{i+1} = (x_i + key + c_i)^5 for every round, and key is added once more
+// at the end. The round constants are those of roundConstants with the seed
+// "mimc", except for c_0 = 0.
+func MiMC(f *field.Field, key, x *field.Element) *field.Element {
+
+	var constants = mimcConstants(f)
+
+	var state = new(field.Element).Set(x)
+
+	var i int
+	for i = range constants {
+		state.Add(state, key)
+		state.Add(state, constants[i])
+		state = pow5(state)
+	}
+
+	return state.Add(state, key)
+}
+
+// MiMCHash hashes the inputs with MiMC in the Miyaguchi-Preneel mode, where
+// each input m updates h to MiMC(h, m) + h + m, starting from h = 0.
+func MiMCHash(f *field.Field, inputs ...*field.Element) *field.Element {
+
+	var h = f.Zero()
+
+	var m *field.Element
+	for _, m = range inputs {
+
+		var next = MiMC(f, h, m)
+		next.Add(next, h)
+
+		h = next.Add(next, m)
+	}
+
+	return h
+}
+
+// MiMC is the circuit of the MiMC cipher, which matches the native MiMC with
+// three constraints per round for x^5.
+func (c *Circuit) MiMC(key, x Variable) Variable {
+
+	var constants = mimcConstants(c.Field)
+
+	var state = x
+
+	var i int
+	for i = range constants {
+		state = c.pow5(c.Add(state, key, c.ConstantElement(constants[i])))
+	}
+
+	return c.Add(state, key)
+}
+
+// MiMCHash is the circuit of the native MiMCHash.
+func (c *Circuit) MiMCHash(inputs ...Variable) Variable {
+
+	var h = c.Constant(0)
+
+	var m Variable
+	for _, m = range inputs {
+		h = c.Add(c.MiMC(h, m), h, m)
+	}
+
+	return h
+}
+
+// pow5 computes x^5 with three multiplications.
+func (c *Circuit) pow5(x Variable) Variable {
+
+	var x2 = c.Mul(x, x)
+	var x4 = c.Mul(x2, x2)
+
+	return c.Mul(x4, x)
+}
+
+func pow5(x *field.Element) *field.Element {
+	return new(field.Element).Exp(x, big.NewInt(5))
+}
+
+func mimcConstants(f *field.Field) []*field.Element {
+
+	var constants = roundConstants(f, "mimc", MiMCRounds)
+	constants[0] = f.Zero()
+
+	return constants
+}
+
+// roundConstants derives n constants from the seed as SHA-256(seed || i)
+// reduced modulo the order of the field, with the index i as a big-endian
+// 32 bit integer.
+func roundConstants(f *field.Field, seed string, n int) []*field.Element {
+
+	var constants = make([]*field.Element, n)
+
+	var index [4]byte
+
+	var i int
+	for i = range constants {
+
+		binary.BigEndian.PutUint32(index[:], uint32(i))
+
+		var digest = sha256.Sum256(append([]byte(seed), index[:]...))
+
+		constants[i] = f.NewElement(new(big.Int).SetBytes(digest[:]))
+	}
+
+	return constants
+}
@@ -0,0 +1,64 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestMiMC(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+	var out = c.Output("out", c.MiMC(c.PrivateInput("key"), c.PrivateInput("x")))
+
+	if len(c.R1CS().Constraints) != 3*MiMCRounds+1 {
+		t.Errorf("constraints = %d, expected %d", len(c.R1CS().Constraints), 3*MiMCRounds+1)
+	}
+
+	var witness = solve(t, c, map[string]int64{"key": 7, "x": 42})
+	if witness == nil {
+		t.Fatal("mimc circuit is not satisfied")
+	}
+
+	var expected = MiMC(f, f.NewInt64(7), f.NewInt64(42))
+
+	if !witness[index(out)].Equal(expected) {
+		t.Errorf("circuit computes %s, expected %s", witness[index(out)].Big(), expected.Big())
+	}
+
+	if MiMC(f, f.NewInt64(8), f.NewInt64(42)).Equal(expected) {
+		t.Error("mimc does not depend on the key")
+	}
+
+	witness[index(out)].Add(witness[index(out)], f.One())
+
+	if c.R1CS().IsSatisfied(witness) == nil {
+		t.Error("tampered output satisfies the mimc circuit")
+	}
+}
+
+func TestMiMCHash(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+	var out = c.Output("out", c.MiMCHash(c.PrivateInput("a"), c.PrivateInput("b"), c.PrivateInput("c")))
+
+	var witness = solve(t, c, map[string]int64{"a": 1, "b": 2, "c": 3})
+	if witness == nil {
+		t.Fatal("mimc hash circuit is not satisfied")
+	}
+
+	var expected = MiMCHash(f, f.NewInt64(1), f.NewInt64(2), f.NewInt64(3))
+
+	if !witness[index(out)].Equal(expected) {
+		t.Errorf("circuit computes %s, expected %s", witness[index(out)].Big(), expected.Big())
+	}
+
+	if MiMCHash(f, f.NewInt64(1), f.NewInt64(3), f.NewInt64(2)).Equal(expected) {
+		t.Error("mimc hash does not depend on the order of the inputs")
+	}
+}
@@ -0,0 +1,301 @@
+package snark
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// SyntaxError reports where an arithmetic expression could not be parsed.
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Left returns the left operand, or nil for a leaf.
+func (t *BinaryTree) Left() *BinaryTree {
+	return t.left
+}
+
+// Right returns the right operand, or nil for a leaf.
+func (t *BinaryTree) Right() *BinaryTree {
+	return t.right
+}
+
+// Op returns "+", "-" or "*" for an operation and "" for a leaf.
+func (t *BinaryTree) Op() string {
+	return t.op
+}
+
+// Wire returns the wire carrying the value of the node. Variables are
+// numbered from 1 in order of first appearance and constants are multiples of
+// the wire 0 which is always 1. Multiplications get their output wire when
+// the tree is flattened, and additions and subtractions are -1 since they
+// only form linear combinations of other wires.
+func (t *BinaryTree) Wire() int {
+	return t.wire
+}
+
+// Name returns the name of a variable, or "" for any other node.
+func (t *BinaryTree) Name() string {
+	return t.name
+}
+
+// Value returns the value of a constant, or nil for any other node.
+func (t *BinaryTree) Value() *big.Int {
+
+	if t.value == nil {
+		return nil
+	}
+
+	return new(big.Int).Set(t.value)
+}
+
+// IsLeaf reports whether the node is a variable or a constant.
+func (t *BinaryTree) IsLeaf() bool {
+	return t.left == nil && t.right == nil
+}
+
+func (t *BinaryTree) String() string {
+
+	switch {
+	case t.value != nil:
+		return t.value.String()
+	case t.IsLeaf():
+		return t.name
+	}
+
+	return fmt.Sprintf("(%s %s %s)", t.left, t.op, t.right)
+}
+
+// Parse builds the tree of an arithmetic expression such as
+//
+//	x1 * (x2 - 2 * x3) * (x5 + 7 * x6)
+//
+// with the usual precedence of * over + and -, left associativity, and
+// parentheses. Variables are identifiers starting with a letter and
+// constants are non-negative integers; a leading minus is parsed as 0 - x.
+// The variables are numbered as described for Wire.
+func Parse(expression string) (*BinaryTree, error) {
+
+	var err error
+
+	var p = &parser{input: expression, wires: make(map[string]int)}
+
+	var tree *BinaryTree
+	if tree, err = p.expression(); err != nil {
+		return nil, err
+	}
+
+	if p.skip(); p.offset < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.offset])
+	}
+
+	return tree, nil
+}
+
+// Inputs lists the names of the variables of the tree, in the order of their
+// wires.
+func (t *BinaryTree) Inputs() []string {
+
+	var names = make(map[int]string)
+
+	t.walk(func(node *BinaryTree) {
+		if node.IsLeaf() && node.value == nil {
+			names[node.wire] = node.name
+		}
+	})
+
+	var inputs = make([]string, len(names))
+
+	var wire int
+	var name string
+
+	for wire, name = range names {
+		inputs[wire-1] = name
+	}
+
+	return inputs
+}
+
+// walk visits the nodes in order from left to right.
+func (t *BinaryTree) walk(visit func(*BinaryTree)) {
+
+	if t.left != nil {
+		t.left.walk(visit)
+	}
+
+	visit(t)
+
+	if t.right != nil {
+		t.right.walk(visit)
+	}
+}
+
+// parser is a recursive descent parser for the grammar
+//
+//	expression = term { ("+" | "-") term }
+//	term       = factor { "*" factor }
+//	factor     = number | identifier | "(" expression ")" | "-" factor
+type parser struct {
+	input  string
+	offset int
+
+	wires map[string]int
+}
+
+func (p *parser) expression() (*BinaryTree, error) {
+
+	var err error
+
+	var left *BinaryTree
+	if left, err = p.term(); err != nil {
+		return nil, err
+	}
+
+	for p.skip(); p.peek('+') || p.peek('-'); p.skip() {
+
+		var op = string(p.input[p.offset])
+		p.offset++
+
+		var right *BinaryTree
+		if right, err = p.term(); err != nil {
+			return nil, err
+		}
+
+		left = &BinaryTree{left: left, right: right, op: op, wire: -1}
+	}
+
+	return left, nil
+}
+
+func (p *parser) term() (*BinaryTree, error) {
+
+	var err error
+
+	var left *BinaryTree
+	if left, err = p.factor(); err != nil {
+		return nil, err
+	}
+
+	for p.skip(); p.peek('*'); p.skip() {
+
+		p.offset++
+
+		var right *BinaryTree
+		if right, err = p.factor(); err != nil {
+			return nil, err
+		}
+
+		left = &BinaryTree{left: left, right: right, op: "*", wire: -1}
+	}
+
+	return left, nil
+}
+
+func (p *parser) factor() (*BinaryTree, error) {
+
+	var err error
+
+	p.skip()
+
+	if p.offset == len(p.input) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+
+	var start = p.offset
+	var c = p.input[p.offset]
+
+	switch {
+	case c == '(':
+
+		p.offset++
+
+		var tree *BinaryTree
+		if tree, err = p.expression(); err != nil {
+			return nil, err
+		}
+
+		if p.skip(); !p.peek(')') {
+			return nil, p.errorf("expected ')'")
+		}
+
+		p.offset++
+
+		return tree, nil
+
+	case c == '-':
+
+		p.offset++
+
+		var operand *BinaryTree
+		if operand, err = p.factor(); err != nil {
+			return nil, err
+		}
+
+		var zero = &BinaryTree{value: new(big.Int), wire: 0}
+
+		return &BinaryTree{left: zero, right: operand, op: "-", wire: -1}, nil
+
+	case isDigit(c):
+
+		for p.offset < len(p.input) && isDigit(p.input[p.offset]) {
+			p.offset++
+		}
+
+		var value, _ = new(big.Int).SetString(p.input[start:p.offset], 10)
+
+		return &BinaryTree{value: value, wire: 0}, nil
+
+	case isLetter(c):
+
+		for p.offset < len(p.input) && (isLetter(p.input[p.offset]) || isDigit(p.input[p.offset])) {
+			p.offset++
+		}
+
+		var name = p.input[start:p.offset]
+
+		var wire, ok = p.wires[name]
+		if !ok {
+			wire = len(p.wires) + 1
+			p.wires[name] = wire
+		}
+
+		return &BinaryTree{name: name, wire: wire}, nil
+	}
+
+	return nil, p.errorf("unexpected %q", c)
+}
+
+// skip advances past white space.
+func (p *parser) skip() {
+
+	for p.offset < len(p.input) {
+
+		switch p.input[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) peek(c byte) bool {
+	return p.offset < len(p.input) && p.input[p.offset] == c
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Offset: p.offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,86 @@
+package snark
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	var err error
+
+	var cases = []struct {
+		expression string
+		expected   string
+	}{
+		{"x1 * (x2 - 2 * x3) * (x5 + 7 * x6)", "((x1 * (x2 - (2 * x3))) * (x5 + (7 * x6)))"},
+		{"x * x * x + x + 5", "((((x * x) * x) + x) + 5)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"-x + 3", "((0 - x) + 3)"},
+		{" ( ( y ) ) ", "y"},
+	}
+
+	var c = cases[0]
+	for _, c = range cases {
+
+		var tree *BinaryTree
+		if tree, err = Parse(c.expression); err != nil {
+			t.Errorf("%q: %v", c.expression, err)
+			continue
+		}
+
+		if tree.String() != c.expected {
+			t.Errorf("%q parsed as %s, expected %s", c.expression, tree, c.expected)
+		}
+	}
+
+	var tree *BinaryTree
+	if tree, err = Parse("x1 * (x2 - 2 * x3) * (x5 + 7 * x6) + x1"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var inputs = tree.Inputs()
+	var expected = []string{"x1", "x2", "x3", "x5", "x6"}
+
+	if len(inputs) != len(expected) {
+		t.Fatalf("inputs = %v, expected %v", inputs, expected)
+	}
+
+	var i int
+	for i = range expected {
+		if inputs[i] != expected[i] {
+			t.Errorf("inputs = %v, expected %v", inputs, expected)
+		}
+	}
+
+	// The repeated x1 refers to the same wire.
+	if tree.Right().Wire() != 1 || tree.Right().Name() != "x1" {
+		t.Errorf("wire of %s = %d, expected 1", tree.Right(), tree.Right().Wire())
+	}
+
+	var invalid = []struct {
+		expression string
+		offset     int
+	}{
+		{"", 0},
+		{"x1 *", 4},
+		{"(x1 + x2", 8},
+		{"x1 + x2)", 7},
+		{"x1 / x2", 3},
+	}
+
+	var syntax *SyntaxError
+	var ok bool
+
+	var d = invalid[0]
+	for _, d = range invalid {
+
+		if _, err = Parse(d.expression); err == nil {
+			t.Errorf("%q: expected a syntax error", d.expression)
+			continue
+		}
+
+		if syntax, ok = err.(*SyntaxError); !ok || syntax.Offset != d.offset {
+			t.Errorf("%q: %v, expected a syntax error at offset %d", d.expression, err, d.offset)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package snark
+
+import (
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// The Poseidon permutation (eprint 2019/458) on a state of PoseidonWidth
+// elements, with the S-box x^5. The rounds follow the recommendation of the
+// paper for a 256 bit field and width 3: 8 full rounds, half of them before
+// and half after the 57 partial rounds.
+const (
+	PoseidonWidth         = 3
+	PoseidonFullRounds    = 8
+	PoseidonPartialRounds = 57
+)
+
+// Poseidon hashes the inputs with the Poseidon sponge. The first element of
+// the state is the capacity, set to the number of inputs for domain
+// separation, and the inputs are added to the other two in pairs with a
+// permutation after each pair. The hash is the second element of the state.
+func Poseidon(f *field.Field, inputs ...*field.Element) *field.Element {
+
+	var state = []*field.Element{f.NewInt64(int64(len(inputs))), f.Zero(), f.Zero()}
+
+	var constants = roundConstants(f, "poseidon", (PoseidonFullRounds+PoseidonPartialRounds)*PoseidonWidth)
+	var mds = poseidonMDS(f)
+
+	var absorb = func(chunk []*field.Element) {
+
+		var i int
+		for i = range chunk {
+			state[i+1].Add(state[i+1], chunk[i])
+		}
+
+		var round int
+		for round = 0; round < PoseidonFullRounds+PoseidonPartialRounds; round++ {
+
+			for i = range state {
+				state[i].Add(state[i], constants[round*PoseidonWidth+i])
+			}
+
+			for i = range state {
+				if i == 0 || isFullRound(round) {
+					state[i] = pow5(state[i])
+				}
+			}
+
+			var mixed = make([]*field.Element, PoseidonWidth)
+
+			for i = range mixed {
+
+				mixed[i] = f.Zero()
+
+				var j int
+				for j = range state {
+					mixed[i].Add(mixed[i], new(field.Element).Mul(mds[i][j], state[j]))
+				}
+			}
+
+			state = mixed
+		}
+	}
+
+	var chunks = chunk(len(inputs))
+
+	var k int
+	for k = range chunks {
+		absorb(inputs[chunks[k][0]:chunks[k][1]])
+	}
+
+	return state[1]
+}
+
+// Poseidon is the circuit of the native Poseidon. The S-boxes cost three
+// constraints each, while adding the round constants and mixing the state are
+// linear and free.
+func (c *Circuit) Poseidon(inputs ...Variable) Variable {
+
+	var state = []Variable{c.Constant(int64(len(inputs))), c.Constant(0), c.Constant(0)}
+
+	var constants = roundConstants(c.Field, "poseidon", (PoseidonFullRounds+PoseidonPartialRounds)*PoseidonWidth)
+	var mds = poseidonMDS(c.Field)
+
+	var absorb = func(chunk []Variable) {
+
+		var i int
+		for i = range chunk {
+			state[i+1] = c.Add(state[i+1], chunk[i])
+		}
+
+		var round int
+		for round = 0; round < PoseidonFullRounds+PoseidonPartialRounds; round++ {
+
+			for i = range state {
+				state[i] = c.Add(state[i], c.ConstantElement(constants[round*PoseidonWidth+i]))
+			}
+
+			for i = range state {
+				if i == 0 || isFullRound(round) {
+					state[i] = c.pow5(state[i])
+				}
+			}
+
+			var mixed = make([]Variable, PoseidonWidth)
+
+			for i = range mixed {
+
+				var j int
+				for j = range state {
+					mixed[i] = c.Add(mixed[i], c.Scale(state[j], mds[i][j]))
+				}
+			}
+
+			state = mixed
+		}
+	}
+
+	var chunks = chunk(len(inputs))
+
+	var k int
+	for k = range chunks {
+		absorb(inputs[chunks[k][0]:chunks[k][1]])
+	}
+
+	return state[1]
+}
+
+// poseidonMDS is the Cauchy matrix M[i][j] = 1 / (i + (width + j)), which is
+// maximum distance separable since all the i and width + j are distinct.
+func poseidonMDS(f *field.Field) [][]*field.Element {
+
+	var mds = make([][]*field.Element, PoseidonWidth)
+
+	var i, j int
+	for i = range mds {
+
+		mds[i] = make([]*field.Element, PoseidonWidth)
+
+		for j = range mds[i] {
+			mds[i][j] = f.NewInt64(int64(i + PoseidonWidth + j))
+			mds[i][j].Inv(mds[i][j])
+		}
+	}
+
+	return mds
+}
+
+func isFullRound(round int) bool {
+	return round < PoseidonFullRounds/2 || round >= PoseidonFullRounds/2+PoseidonPartialRounds
+}
+
+// chunk splits n inputs into the ranges absorbed by each permutation, which
+// is a single empty range when there are no inputs.
+func chunk(n int) [][2]int {
+
+	var chunks [][2]int
+
+	var start int
+	for start = 0; start < n; start += PoseidonWidth - 1 {
+		chunks = append(chunks, [2]int{start, min(start+PoseidonWidth-1, n)})
+	}
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, [2]int{0, 0})
+	}
+
+	return chunks
+}
+
+func min(a, b int) int {
+
+	if a < b {
+		return a
+	}
+
+	return b
+}
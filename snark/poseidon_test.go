@@ -0,0 +1,74 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestPoseidon(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var tests = []struct {
+		inputs []int64
+
+		// The S-boxes of constants are folded, which saves three
+		// constraints in the first round for the capacity and three more
+		// for a rate element left at zero.
+		constraints int
+	}{
+		{inputs: nil, constraints: 0},
+		{inputs: []int64{1}, constraints: 237},
+		{inputs: []int64{1, 2}, constraints: 240},
+		{inputs: []int64{1, 2, 3}, constraints: 240 + 243},
+	}
+
+	var test struct {
+		inputs      []int64
+		constraints int
+	}
+
+	for _, test = range tests {
+
+		var c = NewCircuit(f)
+
+		var variables = make([]Variable, len(test.inputs))
+		var elements = make([]*field.Element, len(test.inputs))
+		var assignment = make(map[string]int64)
+
+		var i int
+		for i = range test.inputs {
+
+			var name = string(rune('a' + i))
+
+			variables[i] = c.PrivateInput(name)
+			elements[i] = f.NewInt64(test.inputs[i])
+			assignment[name] = test.inputs[i]
+		}
+
+		var out = c.Output("out", c.Poseidon(variables...))
+
+		if len(c.R1CS().Constraints) != test.constraints+1 {
+			t.Errorf("%v: constraints = %d, expected %d", test.inputs, len(c.R1CS().Constraints), test.constraints+1)
+		}
+
+		var witness = solve(t, c, assignment)
+		if witness == nil {
+			t.Fatalf("%v: poseidon circuit is not satisfied", test.inputs)
+		}
+
+		var expected = Poseidon(f, elements...)
+
+		if !witness[index(out)].Equal(expected) {
+			t.Errorf("%v: circuit computes %s, expected %s", test.inputs, witness[index(out)].Big(), expected.Big())
+		}
+	}
+
+	// The number of inputs is absorbed in the capacity, so padding with
+	// zeros changes the hash.
+	if Poseidon(f, f.One()).Equal(Poseidon(f, f.One(), f.Zero())) {
+		t.Error("poseidon does not separate inputs of different lengths")
+	}
+}
@@ -0,0 +1,233 @@
+package snark
+
+// The constants of SHA-256 from FIPS 180-4: the first 32 bits of the
+// fractional parts of the square roots of the first 8 primes for the initial
+// state, and of the cube roots of the first 64 primes for the rounds.
+var (
+	sha256IV = [8]uint32{
+		0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+	}
+
+	sha256K = [64]uint32{
+		0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+		0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+		0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+		0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+		0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+		0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+		0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+		0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+	}
+)
+
+// word is a 32 bit word of SHA-256 as its bits, least significant first as
+// for ToBinary.
+type word []Variable
+
+// SHA256 returns the 256 bits of the SHA-256 digest of the message. The bits
+// of the message and of the digest are in the order of FIPS 180-4: byte by
+// byte, each from its most significant bit. The length of the message is
+// fixed when the circuit is built, so the padding only adds constants. The
+// bits of the message are assumed to be boolean, e.g. from ToBinary or
+// AssertBoolean; the digest does not fit in the bn256 scalar field, so it is
+// left as bits.
+func (c *Circuit) SHA256(message []Variable) []Variable {
+
+	var length = uint64(len(message))
+
+	var padded = append([]Variable(nil), message...)
+	padded = append(padded, c.One())
+
+	for len(padded)%512 != 448 {
+		padded = append(padded, c.Constant(0))
+	}
+
+	var i int
+	for i = 63; i >= 0; i-- {
+		padded = append(padded, c.Constant(int64(length>>uint(i)&1)))
+	}
+
+	var state = make([]Variable, 0, 256)
+
+	var h uint32
+	for _, h = range sha256IV {
+		state = append(state, c.constantWord(h).bits()...)
+	}
+
+	for i = 0; i < len(padded); i += 512 {
+		state = c.SHA256Compress(state, padded[i:i+512])
+	}
+
+	return state
+}
+
+// SHA256Compress applies the compression function of SHA-256 to the 256 bits
+// of the chaining state and a block of 512 bits, both in the order of SHA256.
+//
+// The bitwise functions cost one constraint per bit and operand beyond the
+// first, while the additions modulo 2^32 are summed as field elements and
+// decomposed once with ToBinary, with a few extra bits for the carries. A
+// compression takes about 26000 constraints.
+func (c *Circuit) SHA256Compress(state, block []Variable) []Variable {
+
+	if len(state) != 256 || len(block) != 512 {
+		panic("snark: sha256 compresses 256 bits of state and 512 bits of block")
+	}
+
+	var w = make([]word, 64)
+
+	var i int
+	for i = 0; i < 16; i++ {
+		w[i] = toWord(block[32*i : 32*(i+1)])
+	}
+
+	for i = 16; i < 64; i++ {
+
+		var s0 = c.xorWords(w[i-15].rotr(7), w[i-15].rotr(18), w[i-15].shr(c, 3))
+		var s1 = c.xorWords(w[i-2].rotr(17), w[i-2].rotr(19), w[i-2].shr(c, 10))
+
+		w[i] = c.addWords([]word{w[i-16], s0, w[i-7], s1})
+	}
+
+	var h = make([]word, 8)
+
+	for i = range h {
+		h[i] = toWord(state[32*i : 32*(i+1)])
+	}
+
+	var a, b, cc, d, e, f, g, hh = h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+
+	for i = 0; i < 64; i++ {
+
+		var sigma1 = c.xorWords(e.rotr(6), e.rotr(11), e.rotr(25))
+		var sigma0 = c.xorWords(a.rotr(2), a.rotr(13), a.rotr(22))
+
+		// ch = e ? f : g and maj = a xor b ? c : a, one constraint per bit
+		// each beside the xor of maj.
+		var ch = make(word, 32)
+		var maj = make(word, 32)
+
+		var j int
+		for j = range ch {
+			ch[j] = c.Select(e[j], f[j], g[j])
+			maj[j] = c.Select(c.Xor(a[j], b[j]), cc[j], a[j])
+		}
+
+		var t1 = []word{hh, sigma1, ch, c.constantWord(sha256K[i]), w[i]}
+
+		hh, g, f = g, f, e
+		e = c.addWords(append([]word{d}, t1...))
+		d, cc, b = cc, b, a
+		a = c.addWords(append(t1, sigma0, maj))
+	}
+
+	var next = make([]Variable, 0, 256)
+
+	var x word
+	for i, x = range []word{a, b, cc, d, e, f, g, hh} {
+		next = append(next, c.addWords([]word{h[i], x}).bits()...)
+	}
+
+	return next
+}
+
+// toWord converts 32 bits from the most significant one first.
+func toWord(bits []Variable) word {
+
+	var x = make(word, 32)
+
+	var i int
+	for i = range x {
+		x[i] = bits[31-i]
+	}
+
+	return x
+}
+
+// bits returns the bits of the word from the most significant one first.
+func (x word) bits() []Variable {
+	return toWord(x)
+}
+
+func (c *Circuit) constantWord(value uint32) word {
+
+	var x = make(word, 32)
+
+	var i int
+	for i = range x {
+		x[i] = c.Constant(int64(value >> uint(i) & 1))
+	}
+
+	return x
+}
+
+func (x word) rotr(n int) word {
+
+	var rotated = make(word, 32)
+
+	var i int
+	for i = range rotated {
+		rotated[i] = x[(i+n)%32]
+	}
+
+	return rotated
+}
+
+func (x word) shr(c *Circuit, n int) word {
+
+	var shifted = make(word, 32)
+
+	var i int
+	for i = range shifted {
+
+		if i+n < 32 {
+			shifted[i] = x[i+n]
+			continue
+		}
+
+		shifted[i] = c.Constant(0)
+	}
+
+	return shifted
+}
+
+// xorWords returns the bitwise xor of the words.
+func (c *Circuit) xorWords(xs ...word) word {
+
+	var sum = xs[0]
+
+	var x word
+	for _, x = range xs[1:] {
+
+		var next = make(word, 32)
+
+		var i int
+		for i = range next {
+			next[i] = c.Xor(sum[i], x[i])
+		}
+
+		sum = next
+	}
+
+	return sum
+}
+
+// addWords returns the sum of the words modulo 2^32. The sum is less than
+// len(xs) * 2^32, so it is decomposed into 32 bits and enough bits for the
+// carries, which are dropped.
+func (c *Circuit) addWords(xs []word) word {
+
+	var sum Variable
+
+	var x word
+	for _, x = range xs {
+		sum = c.Add(sum, c.FromBinary(x))
+	}
+
+	var carries int
+	for 1<<uint(carries) < len(xs) {
+		carries++
+	}
+
+	return word(c.ToBinary(sum, 32+carries)[:32])
+}
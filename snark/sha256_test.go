@@ -0,0 +1,139 @@
+package snark
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/sm"
+)
+
+// bitInputs adds a boolean private input for every bit of n bytes, in the
+// order of SHA256, and sets their values from data in inputs.
+func bitInputs(c *Circuit, data []byte, inputs map[string]int64) []Variable {
+
+	var bits = make([]Variable, 8*len(data))
+
+	var i int
+	for i = range bits {
+
+		var name = fmt.Sprintf("m%d", i)
+
+		bits[i] = c.PrivateInput(name)
+		c.AssertBoolean(bits[i])
+
+		inputs[name] = int64(data[i/8] >> uint(7-i%8) & 1)
+	}
+
+	return bits
+}
+
+// digest reads the bytes of a digest from the witness.
+func digest(f *field.Field, bits []Variable, witness []*field.Element) []byte {
+
+	var bytes = make([]byte, len(bits)/8)
+
+	var i int
+	for i = range bits {
+		if bits[i].LinearCombination().Evaluate(f, witness).IsOne() {
+			bytes[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	return bytes
+}
+
+func TestSHA256(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var tests = []string{
+		"",
+		"abc",
+		"abcdbcdecdefdefgefghfghighijhijkijkljklmjklmnklmnolmnopmnopqnopq",
+	}
+
+	var message string
+	for _, message = range tests {
+
+		var c = NewCircuit(f)
+		var inputs = make(map[string]int64)
+
+		var out = c.SHA256(bitInputs(c, []byte(message), inputs))
+
+		var witness = solve(t, c, inputs)
+		if witness == nil {
+			t.Fatalf("%q: sha256 circuit is not satisfied", message)
+		}
+
+		var expected = sha256.Sum256([]byte(message))
+
+		if fmt.Sprintf("%x", digest(f, out, witness)) != fmt.Sprintf("%x", expected) {
+			t.Errorf("%q: circuit computes %x, expected %x", message, digest(f, out, witness), expected)
+		}
+	}
+}
+
+// candidate builds the candidate of sm.HashToPrime for a nonce and at most
+// 256 bits: the first bits of SHA-256(domain || bits || nonce || 0 || data),
+// with the top and bottom bits set. Only the data is a witness; the prefix is
+// made of constants.
+func candidate(c *Circuit, data []Variable, bits int, nonce uint32) []Variable {
+
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(bits))
+	binary.BigEndian.PutUint32(header[4:], nonce)
+
+	var prefix = append([]byte(sm.HashToPrimeDomain), header[:]...)
+
+	var message []Variable
+
+	var i int
+	for i = 0; i < 8*len(prefix); i++ {
+		message = append(message, c.Constant(int64(prefix[i/8]>>uint(7-i%8)&1)))
+	}
+
+	var out = c.SHA256(append(message, data...))[:bits]
+
+	out[0] = c.One()
+	out[bits-1] = c.One()
+
+	return out
+}
+
+// TestSHA256HashToPrime proves the prime of sm.HashToPrime for an element,
+// given its nonce, which is the expensive step of checking it.
+func TestSHA256HashToPrime(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var element = big.NewInt(66).Bytes()
+
+	var prime, nonce = sm.HashToPrime(element, sm.PrimeBits)
+
+	var c = NewCircuit(f)
+	var inputs = make(map[string]int64)
+
+	var bits = bitInputs(c, element, inputs)
+	var out = candidate(c, bits, sm.PrimeBits, nonce)
+
+	var witness = solve(t, c, inputs)
+	if witness == nil {
+		t.Fatal("hash to prime circuit is not satisfied")
+	}
+
+	if new(big.Int).SetBytes(digest(f, out, witness)).Cmp(prime) != 0 {
+		t.Errorf("circuit computes %x, expected the prime %x", digest(f, out, witness), prime)
+	}
+
+	// A message bit that is flipped after solving breaks the constraints.
+	witness[index(bits[0])] = f.One()
+
+	if c.R1CS().IsSatisfied(witness) == nil {
+		t.Error("tampered element satisfies the hash to prime circuit")
+	}
+}
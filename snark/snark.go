@@ -11,12 +11,18 @@ const (
 	MINUS
 )
 
+// BinaryTree is the parse tree of an arithmetic expression. The operations
+// have both operands, and the leaves are either a variable with a name or a
+// constant with a value.
 type BinaryTree struct {
 	left  *BinaryTree
 	right *BinaryTree
 
 	wire int
 	op   string
+
+	name  string
+	value *big.Int
 }
 
 func E1QAP(order *big.Int) bool {
@@ -0,0 +1,77 @@
+package snark
+
+import (
+	"fmt"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// InputError reports an input of a circuit without a value, or a value for a
+// name that is not an input.
+type InputError struct {
+	Name    string
+	Missing bool
+}
+
+func (e *InputError) Error() string {
+
+	if e.Missing {
+		return fmt.Sprintf("no value for input %q", e.Name)
+	}
+
+	return fmt.Sprintf("%q is not an input of the circuit", e.Name)
+}
+
+// Solve computes the witness of the circuit from the values of its inputs,
+// which are matched to their wires by the names given to PublicInput and
+// PrivateInput.
+// The wires are evaluated in the order they were added, which is a
+// topological order since a wire only depends on the ones before it. The
+// result is in the order of the variables of R1CS, and the constraints are
+// checked before it is returned, so a failed assertion is reported as a
+// *qap.UnsatisfiedError.
+func (c *Circuit) Solve(inputs map[string]*field.Element) ([]*field.Element, error) {
+
+	var err error
+
+	var variables = c.r1cs.Variables
+	var values = make([]*field.Element, len(variables))
+
+	var index int
+	var ok bool
+
+	var name string
+	for name = range inputs {
+		if _, ok = c.inputs[name]; !ok {
+			return nil, &InputError{Name: name}
+		}
+	}
+
+	values[0] = c.Field.One()
+
+	for index = 1; index < len(variables); index++ {
+
+		var hint, computed = c.hints[index]
+		if computed {
+
+			if values[index], err = hint(values[:index]); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		var value *field.Element
+		if value, ok = inputs[variables[index].Name]; !ok {
+			return nil, &InputError{Name: variables[index].Name, Missing: true}
+		}
+
+		values[index] = c.Field.NewElement(value.Big())
+	}
+
+	if err = c.r1cs.IsSatisfied(values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
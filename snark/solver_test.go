@@ -0,0 +1,154 @@
+package snark
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+func TestSolve(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// The expression of example 4, where the intermediate a4 = 4 and the
+	// output a7 = 36 were computed by hand.
+	var tree *BinaryTree
+	if tree, err = Parse("x1 * (x2 - 2 * x3) * (x5 + 7 * x6)"); err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+
+	var c = Flatten(f, tree)
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{
+		"x1": f.NewInt64(4), "x2": f.NewInt64(7), "x3": f.NewInt64(3), "x5": f.NewInt64(2), "x6": f.NewInt64(1),
+	}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	var expected = []int64{1, 4, 7, 3, 2, 1, 4, 36}
+
+	var i int
+	for i = range expected {
+		if !witness[i].Equal(f.NewInt64(expected[i])) {
+			t.Errorf("witness[%d] = %d, expected %d", i, witness[i], expected[i])
+		}
+	}
+
+	var input *InputError
+	var ok bool
+
+	if _, err = c.Solve(map[string]*field.Element{"x1": f.One()}); err == nil {
+		t.Errorf("expected an error for missing inputs")
+	} else if input, ok = err.(*InputError); !ok || input.Name != "x2" || !input.Missing {
+		t.Errorf("expected x2 to be missing, got %v", err)
+	}
+
+	if _, err = c.Solve(map[string]*field.Element{
+		"x1": f.One(), "x2": f.One(), "x3": f.One(), "x5": f.One(), "x6": f.One(), "w1": f.One(),
+	}); err == nil {
+		t.Errorf("expected an error for assigning a computed wire")
+	} else if input, ok = err.(*InputError); !ok || input.Name != "w1" || input.Missing {
+		t.Errorf("expected w1 not to be an input, got %v", err)
+	}
+}
+
+func TestSolveDivision(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// out = x / y + 1 / (x - 3)
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var y = c.PrivateInput("y")
+
+	c.Output("out", c.Add(c.Div(x, y), c.Inverse(c.Sub(x, c.Constant(3)))))
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(5), "y": f.NewInt64(7)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	// 5 / 7 + 1 / 2 = 17 / 14
+	var expected = new(field.Element).Mul(f.NewInt64(17), new(field.Element).Inv(f.NewInt64(14)))
+
+	var index, _ = c.R1CS().Variable("out")
+	if !witness[index].Equal(expected) {
+		t.Errorf("out = %d, expected %d", witness[index], expected)
+	}
+
+	if _, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "y": f.NewInt64(7)}); err != ErrDivisionByZero {
+		t.Errorf("expected %v, got %v", ErrDivisionByZero, err)
+	}
+}
+
+func TestSolveAssertion(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var out = c.PublicInput("out")
+
+	c.AssertEqual(c.Add(c.Mul(c.Mul(x, x), x), x, c.Constant(5)), out)
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "out": f.NewInt64(35)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	if !witness[3].Equal(f.NewInt64(9)) || !witness[4].Equal(f.NewInt64(27)) {
+		t.Errorf("intermediate wires = %d, %d, expected 9, 27", witness[3], witness[4])
+	}
+
+	var ok bool
+	if _, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "out": f.NewInt64(36)}); err == nil {
+		t.Errorf("expected the assertion to fail")
+	} else if _, ok = err.(*qap.UnsatisfiedError); !ok {
+		t.Errorf("expected an unsatisfied constraint, got %v", err)
+	}
+}
+
+func TestSolveInputNames(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var c = NewCircuit(f)
+
+	var x = c.PrivateInput("x")
+	var square = c.Mul(x, x)
+
+	// The input has the name the first gate wire was given, and is still
+	// found by Solve.
+	var w1 = c.PublicInput("w1")
+	c.AssertEqual(square, w1)
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3), "w1": f.NewInt64(9)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	if !witness[3].Equal(f.NewInt64(9)) {
+		t.Errorf("w1 = %d, expected 9", witness[3])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an input declared twice")
+		}
+	}()
+
+	c.PrivateInput("x")
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
 )
 
 // E1SM provides a pedersen commitment, generated a zero-knowledge proof of set
@@ -15,10 +16,12 @@ func E1SM(order *big.Int) bool {
 
 	var err error
 
+	var f = field.New(order)
+
 	// Commitment
 
-	var gamma *big.Int
-	if gamma, err = rand.Int(rand.Reader, order); err != nil {
+	var gamma *field.Element
+	if gamma, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
@@ -27,8 +30,8 @@ func E1SM(order *big.Int) bool {
 		fmt.Printf("error generating group element %v \n", err)
 	}
 
-	var x *big.Int
-	if x, err = rand.Int(rand.Reader, order); err != nil {
+	var x *field.Element
+	if x, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating group element %v \n", err)
 	}
 
@@ -42,12 +45,12 @@ func E1SM(order *big.Int) bool {
 		fmt.Printf("error generating group element %v \n", err)
 	}
 
-	var y = new(bn256.G2).ScalarMult(g2, x)
+	var y = new(bn256.G2).ScalarMult(g2, x.Big())
 
 	var delta int64 = 15
 	var C = new(bn256.G1).Add(
 		new(bn256.G1).ScalarMult(g1, big.NewInt(delta)),
-		new(bn256.G1).ScalarMult(h, gamma),
+		new(bn256.G1).ScalarMult(h, gamma.Big()),
 	)
 
 	var sigs = make(map[int64]*bn256.G1)
@@ -62,12 +65,12 @@ func E1SM(order *big.Int) bool {
 			9, 26, 27, 28, 30,
 		}
 
-		var expo *big.Int
+		var expo *field.Element
 
 		var elem int64
 		for _, elem = range s {
-			expo = new(big.Int).ModInverse(new(big.Int).Add(big.NewInt(elem), x), order)
-			sigs[elem] = new(bn256.G1).ScalarMult(g1, expo)
+			expo = new(field.Element).Inv(new(field.Element).Add(f.NewInt64(elem), x))
+			sigs[elem] = new(bn256.G1).ScalarMult(g1, expo.Big())
 		}
 	}
 
@@ -84,36 +87,36 @@ func E1SM(order *big.Int) bool {
 
 	// Prover
 
-	var tau *big.Int
-	if tau, err = rand.Int(rand.Reader, order); err != nil {
+	var tau *field.Element
+	if tau, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
-	var V = new(bn256.G1).ScalarMult(sigs[delta], tau)
+	var V = new(bn256.G1).ScalarMult(sigs[delta], tau.Big())
 
-	var s *big.Int
-	if s, err = rand.Int(rand.Reader, order); err != nil {
+	var s *field.Element
+	if s, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
-	var t *big.Int
-	if t, err = rand.Int(rand.Reader, order); err != nil {
+	var t *field.Element
+	if t, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
-	var m *big.Int
-	if m, err = rand.Int(rand.Reader, order); err != nil {
+	var m *field.Element
+	if m, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
 	var a = new(bn256.GT).Add(
-		new(bn256.GT).ScalarMult(bn256.Pair(V, g2), new(big.Int).Sub(order, s)),
-		new(bn256.GT).ScalarMult(bn256.Pair(g1, g2), t),
+		new(bn256.GT).ScalarMult(bn256.Pair(V, g2), new(field.Element).Neg(s).Big()),
+		new(bn256.GT).ScalarMult(bn256.Pair(g1, g2), t.Big()),
 	)
 
 	var D = new(bn256.G1).Add(
-		new(bn256.G1).ScalarMult(g1, s),
-		new(bn256.G1).ScalarMult(h, m),
+		new(bn256.G1).ScalarMult(g1, s.Big()),
+		new(bn256.G1).ScalarMult(h, m.Big()),
 	)
 
 	// Verifier
@@ -121,39 +124,28 @@ func E1SM(order *big.Int) bool {
 	// NOTE: The proof proof can be made non-interactive by defining c as the
 	// hash of a and D.
 
-	var c *big.Int
-	if c, err = rand.Int(rand.Reader, order); err != nil {
+	var c *field.Element
+	if c, err = f.Rand(rand.Reader); err != nil {
 		fmt.Printf("error generating field element %v \n", err)
 	}
 
-	// var zTau = new(big.Int).Sub(t, new(big.Int).Mul(tau, c))
-	var zTau = new(big.Int).Add(t, new(big.Int).Sub(order, new(big.Int).Mul(tau, c)))
-	// var zGamma = new(big.Int).Sub(m, new(big.Int).Mul(gamma, c))
-	var zGamma = new(big.Int).Add(m, new(big.Int).Sub(order, new(big.Int).Mul(gamma, c)))
-	// var zDelta = new(big.Int).Sub(s, new(big.Int).Mul(big.NewInt(delta), c))
-	var zDelta = new(big.Int).Add(s, new(big.Int).Sub(order, new(big.Int).Mul(big.NewInt(delta), c)))
-
-	c = new(big.Int).Mod(c, order)
-	zGamma = new(big.Int).Mod(zGamma, order)
-	zDelta = new(big.Int).Mod(zDelta, order)
+	var zTau = new(field.Element).Sub(t, new(field.Element).Mul(tau, c))
+	var zGamma = new(field.Element).Sub(m, new(field.Element).Mul(gamma, c))
+	var zDelta = new(field.Element).Sub(s, new(field.Element).Mul(f.NewInt64(delta), c))
 
 	var left = new(bn256.G1).Add(
-		new(bn256.G1).ScalarMult(C, c),
+		new(bn256.G1).ScalarMult(C, c.Big()),
 		new(bn256.G1).Add(
-			new(bn256.G1).ScalarMult(h, zGamma),
-			new(bn256.G1).ScalarMult(g1, zDelta),
+			new(bn256.G1).ScalarMult(h, zGamma.Big()),
+			new(bn256.G1).ScalarMult(g1, zDelta.Big()),
 		),
 	)
 
-	c = new(big.Int).Mod(c, order)
-	zDelta = new(big.Int).Mod(zDelta, order)
-	zTau = new(big.Int).Mod(zTau, order)
-
 	var right = new(bn256.GT).Add(
-		new(bn256.GT).ScalarMult(bn256.Pair(V, y), c),
+		new(bn256.GT).ScalarMult(bn256.Pair(V, y), c.Big()),
 		new(bn256.GT).Add(
-			new(bn256.GT).ScalarMult(bn256.Pair(V, g2), new(big.Int).Sub(order, zDelta)),
-			new(bn256.GT).ScalarMult(bn256.Pair(g1, g2), zTau),
+			new(bn256.GT).ScalarMult(bn256.Pair(V, g2), new(field.Element).Neg(zDelta).Big()),
+			new(bn256.GT).ScalarMult(bn256.Pair(g1, g2), zTau.Big()),
 		),
 	)
 
@@ -0,0 +1,466 @@
+// Package circom reads and writes the binary .r1cs and .wtns files of the
+// iden3 tools, circom and snarkjs, so circuits prototyped there can be
+// compiled to QAPs and proved with the other packages, and the other way
+// around.
+//
+// Both formats start with a magic string, a version and the number of
+// sections, followed by the sections as a type, a size in bytes and their
+// content. Integers are little-endian, and so are the field elements, which
+// take the size of the prime rounded up to 8 bytes.
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// ErrFormat is returned when a file does not have the magic string, version
+// or sections of its format.
+var ErrFormat = errors.New("file is not in the expected format")
+
+// ErrNotReduced is returned when a field element of a file is not less than
+// the prime of the file.
+var ErrNotReduced = errors.New("field element is not reduced")
+
+// The sections of a .r1cs file and of a .wtns file.
+const (
+	sectionHeader      = 1
+	sectionConstraints = 2
+	sectionLabels      = 3
+
+	sectionWitness = 2
+)
+
+// ReadR1CS reads a .r1cs file of version 1. The wires become the variables
+// in the same order, where the first wire is the constant 1 and the public
+// outputs and inputs follow, and are named w1, w2, ... since the names are
+// only in the .sym file. The labels map every wire, so their section bounds
+// the number of wires, and each constraint takes at least 12 bytes, which
+// bounds their number before anything is allocated. The labels themselves,
+// and other sections such as custom gates, are ignored.
+func ReadR1CS(r io.Reader) (*qap.R1CS, error) {
+
+	var err error
+
+	var sections map[uint32][]byte
+	if sections, err = readSections(r, "r1cs", 1); err != nil {
+		return nil, err
+	}
+
+	if sections[sectionHeader] == nil || sections[sectionConstraints] == nil || sections[sectionLabels] == nil {
+		return nil, ErrFormat
+	}
+
+	var header = &decoder{b: sections[sectionHeader]}
+
+	var n8 = int(header.uint32())
+	var f = field.New(header.prime(n8))
+
+	var wires = int(header.uint32())
+	var public = int(header.uint32()) + int(header.uint32())
+
+	header.uint32() // private inputs
+	header.uint64() // labels
+
+	var constraints = int(header.uint32())
+
+	if header.err != nil {
+		return nil, header.err
+	}
+
+	if wires < 1 || public >= wires || wires > len(sections[sectionLabels])/8 {
+		return nil, ErrFormat
+	}
+
+	if constraints > len(sections[sectionConstraints])/12 {
+		return nil, ErrFormat
+	}
+
+	var r1cs = qap.NewR1CS(f)
+
+	var i int
+	for i = 1; i < wires; i++ {
+		r1cs.AddVariable(fmt.Sprintf("w%d", i), i <= public)
+	}
+
+	var d = &decoder{b: sections[sectionConstraints], f: f}
+
+	for i = 0; i < constraints; i++ {
+
+		var a, b, c = d.combination(n8, wires), d.combination(n8, wires), d.combination(n8, wires)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		r1cs.AddConstraint(a, b, c)
+	}
+
+	return r1cs, nil
+}
+
+// WriteR1CS writes a constraint system as a .r1cs file of version 1. The
+// format puts the public variables first, so the variables are written in
+// the order of Wires, all the public ones as public inputs. The label of
+// every wire is the index of its variable in r1cs.
+func WriteR1CS(w io.Writer, r1cs *qap.R1CS) error {
+
+	var n8 = size(r1cs.Field.Modulus())
+
+	var wires = Wires(r1cs)
+	var positions = make([]int, len(wires))
+
+	var i, k int
+	for i, k = range wires {
+		positions[k] = i
+	}
+
+	var header = &encoder{}
+
+	header.uint32(uint32(n8))
+	header.element(r1cs.Field.Modulus(), n8)
+	header.uint32(uint32(len(wires)))
+	header.uint32(0)
+	header.uint32(uint32(len(r1cs.Public()) - 1))
+	header.uint32(0)
+	header.uint64(uint64(len(wires)))
+	header.uint32(uint32(len(r1cs.Constraints)))
+
+	var constraints = &encoder{}
+
+	var constraint qap.Constraint
+	for _, constraint = range r1cs.Constraints {
+
+		var lc qap.LinearCombination
+		for _, lc = range []qap.LinearCombination{constraint.A, constraint.B, constraint.C} {
+
+			constraints.uint32(uint32(len(lc)))
+
+			var term qap.Term
+			for _, term = range lc {
+				constraints.uint32(uint32(positions[term.Index]))
+				constraints.element(term.Coeff.Big(), n8)
+			}
+		}
+	}
+
+	var labels = &encoder{}
+
+	for _, k = range wires {
+		labels.uint64(uint64(k))
+	}
+
+	return writeSections(w, "r1cs", 1, header, constraints, labels)
+}
+
+// Wires returns the order in which WriteR1CS and WriteWitness write the
+// variables: the constant, the other public variables and then the private
+// ones, each in their order in r1cs.
+func Wires(r1cs *qap.R1CS) []int {
+
+	var wires = r1cs.Public()
+
+	var k int
+	for k = range r1cs.Variables {
+		if !r1cs.Variables[k].Public {
+			wires = append(wires, k)
+		}
+	}
+
+	return wires
+}
+
+// ReadWitness reads a .wtns file of version 2, with the values in the order
+// of the wires of its .r1cs file.
+func ReadWitness(r io.Reader) ([]*field.Element, error) {
+
+	var err error
+
+	var sections map[uint32][]byte
+	if sections, err = readSections(r, "wtns", 2); err != nil {
+		return nil, err
+	}
+
+	if sections[sectionHeader] == nil || sections[sectionWitness] == nil {
+		return nil, ErrFormat
+	}
+
+	var header = &decoder{b: sections[sectionHeader]}
+
+	var n8 = int(header.uint32())
+	var f = field.New(header.prime(n8))
+	var n = int(header.uint32())
+
+	if header.err != nil {
+		return nil, header.err
+	}
+
+	if n > len(sections[sectionWitness])/n8 {
+		return nil, ErrFormat
+	}
+
+	var d = &decoder{b: sections[sectionWitness], f: f}
+
+	var witness = make([]*field.Element, n)
+
+	var i int
+	for i = range witness {
+		witness[i] = d.element(n8)
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return witness, nil
+}
+
+// WriteWitness writes a witness of r1cs as a .wtns file of version 2, in the
+// order of the wires of the file written by WriteR1CS.
+func WriteWitness(w io.Writer, r1cs *qap.R1CS, witness []*field.Element) error {
+
+	if len(witness) != len(r1cs.Variables) {
+		return qap.ErrWitnessLength
+	}
+
+	var n8 = size(r1cs.Field.Modulus())
+
+	var header = &encoder{}
+
+	header.uint32(uint32(n8))
+	header.element(r1cs.Field.Modulus(), n8)
+	header.uint32(uint32(len(witness)))
+
+	var values = &encoder{}
+
+	var k int
+	for _, k = range Wires(r1cs) {
+		values.element(witness[k].Big(), n8)
+	}
+
+	return writeSections(w, "wtns", 2, header, values)
+}
+
+// size is the number of bytes of the field elements, which is the size of the
+// prime rounded up to a multiple of 8.
+func size(p *big.Int) int {
+	return (p.BitLen()-1)/64*8 + 8
+}
+
+func readSections(r io.Reader, magic string, version uint32) (map[uint32][]byte, error) {
+
+	var err error
+
+	var b []byte
+	if b, err = ioutil.ReadAll(r); err != nil {
+		return nil, err
+	}
+
+	if len(b) < 4 || string(b[:4]) != magic {
+		return nil, ErrFormat
+	}
+
+	var d = &decoder{b: b[4:]}
+
+	if d.uint32() != version {
+		return nil, ErrFormat
+	}
+
+	var count = d.uint32()
+
+	var sections = make(map[uint32][]byte)
+
+	var i uint32
+	for i = 0; i < count && d.err == nil; i++ {
+
+		var typ = d.uint32()
+		var length = d.uint64()
+
+		if d.err == nil && length > uint64(len(d.b)) {
+			return nil, ErrFormat
+		}
+
+		sections[typ] = d.bytes(int(length))
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return sections, nil
+}
+
+func writeSections(w io.Writer, magic string, version uint32, sections ...*encoder) error {
+
+	var err error
+
+	var file = &encoder{}
+
+	file.WriteString(magic)
+	file.uint32(version)
+	file.uint32(uint32(len(sections)))
+
+	var i int
+	var section *encoder
+
+	for i, section = range sections {
+		file.uint32(uint32(i + 1))
+		file.uint64(uint64(section.Len()))
+		file.Write(section.Bytes())
+	}
+
+	_, err = w.Write(file.Bytes())
+
+	return err
+}
+
+// decoder reads little-endian values from b, and keeps the first error so
+// the checks can be made after a run of reads.
+type decoder struct {
+	b   []byte
+	f   *field.Field
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+
+	if d.err != nil {
+		return nil
+	}
+
+	if n > len(d.b) {
+		d.err = ErrFormat
+		return nil
+	}
+
+	var b = d.b[:n]
+	d.b = d.b[n:]
+
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+
+	var b = d.bytes(4)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (d *decoder) uint64() uint64 {
+
+	var b = d.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (d *decoder) integer(n int) *big.Int {
+
+	var b = d.bytes(n)
+
+	var reversed = make([]byte, len(b))
+
+	var i int
+	for i = range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+
+	return new(big.Int).SetBytes(reversed)
+}
+
+// prime reads the prime of the header, which must be odd, at least 3, and
+// take exactly n bytes once rounded up to a multiple of 8.
+func (d *decoder) prime(n int) *big.Int {
+
+	var p = d.integer(n)
+
+	if d.err == nil && (p.Cmp(big.NewInt(3)) < 0 || p.Bit(0) == 0 || size(p) != n) {
+		d.err = ErrFormat
+	}
+
+	return p
+}
+
+func (d *decoder) element(n int) *field.Element {
+
+	var x = d.integer(n)
+
+	if d.err == nil && x.Cmp(d.f.Modulus()) >= 0 {
+		d.err = ErrNotReduced
+	}
+
+	return d.f.NewElement(x)
+}
+
+// combination reads a linear combination as a number of terms, each a wire
+// and a coefficient.
+func (d *decoder) combination(n8, wires int) qap.LinearCombination {
+
+	var count = d.uint32()
+
+	var lc qap.LinearCombination
+
+	var i uint32
+	for i = 0; i < count && d.err == nil; i++ {
+
+		var wire = int(d.uint32())
+
+		if d.err == nil && wire >= wires {
+			d.err = ErrFormat
+		}
+
+		lc = append(lc, qap.Term{Index: wire, Coeff: d.element(n8)})
+	}
+
+	return lc
+}
+
+// encoder writes little-endian values.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) uint32(x uint32) {
+
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], x)
+
+	e.Write(b[:])
+}
+
+func (e *encoder) uint64(x uint64) {
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+
+	e.Write(b[:])
+}
+
+// element writes a non-negative integer in n bytes.
+func (e *encoder) element(x *big.Int, n int) {
+
+	var b = make([]byte, n)
+
+	var i int
+	var c byte
+
+	var be = x.Bytes()
+	for i, c = range be {
+		b[len(be)-1-i] = c
+	}
+
+	e.Write(b)
+}
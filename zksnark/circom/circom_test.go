@@ -0,0 +1,282 @@
+package circom
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/snark"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// multiplier is the .r1cs file of the circom circuit
+//
+//	template Multiplier() {
+//	    signal input a;
+//	    signal input b;
+//	    signal output c;
+//	    c <== a * b;
+//	}
+//
+// over the field of order 23, with the wires one, c, a and b. As circom does,
+// the constraint is written as (-a) * (b) = (-c).
+var multiplier = []byte{
+	'r', '1', 'c', 's',
+	1, 0, 0, 0, // version
+	3, 0, 0, 0, // sections
+
+	1, 0, 0, 0, // header
+	40, 0, 0, 0, 0, 0, 0, 0,
+	8, 0, 0, 0, // field size
+	23, 0, 0, 0, 0, 0, 0, 0, // prime
+	4, 0, 0, 0, // wires
+	1, 0, 0, 0, // public outputs
+	0, 0, 0, 0, // public inputs
+	2, 0, 0, 0, // private inputs
+	4, 0, 0, 0, 0, 0, 0, 0, // labels
+	1, 0, 0, 0, // constraints
+
+	2, 0, 0, 0, // constraints
+	48, 0, 0, 0, 0, 0, 0, 0,
+	1, 0, 0, 0, 2, 0, 0, 0, 22, 0, 0, 0, 0, 0, 0, 0, // A = 22 * a
+	1, 0, 0, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, // B = 1 * b
+	1, 0, 0, 0, 1, 0, 0, 0, 22, 0, 0, 0, 0, 0, 0, 0, // C = 22 * c
+
+	3, 0, 0, 0, // labels
+	32, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0,
+	1, 0, 0, 0, 0, 0, 0, 0,
+	2, 0, 0, 0, 0, 0, 0, 0,
+	3, 0, 0, 0, 0, 0, 0, 0,
+}
+
+// multiplierWitness is the .wtns file of the multiplier for a = 3 and b = 5.
+var multiplierWitness = []byte{
+	'w', 't', 'n', 's',
+	2, 0, 0, 0, // version
+	2, 0, 0, 0, // sections
+
+	1, 0, 0, 0, // header
+	16, 0, 0, 0, 0, 0, 0, 0,
+	8, 0, 0, 0, // field size
+	23, 0, 0, 0, 0, 0, 0, 0, // prime
+	4, 0, 0, 0, // values
+
+	2, 0, 0, 0, // values
+	32, 0, 0, 0, 0, 0, 0, 0,
+	1, 0, 0, 0, 0, 0, 0, 0,
+	15, 0, 0, 0, 0, 0, 0, 0,
+	3, 0, 0, 0, 0, 0, 0, 0,
+	5, 0, 0, 0, 0, 0, 0, 0,
+}
+
+func TestReadR1CS(t *testing.T) {
+
+	var err error
+
+	var r1cs *qap.R1CS
+	if r1cs, err = ReadR1CS(bytes.NewReader(multiplier)); err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+
+	if r1cs.Field.Modulus().Cmp(big.NewInt(23)) != 0 {
+		t.Errorf("prime = %s, expected 23", r1cs.Field.Modulus())
+	}
+
+	if len(r1cs.Variables) != 4 || len(r1cs.Constraints) != 1 {
+		t.Fatalf("variables = %d, constraints = %d, expected 4 and 1", len(r1cs.Variables), len(r1cs.Constraints))
+	}
+
+	var public = r1cs.Public()
+	if len(public) != 2 || public[1] != 1 {
+		t.Errorf("public = %v, expected [0 1]", public)
+	}
+
+	var witness []*field.Element
+	if witness, err = ReadWitness(bytes.NewReader(multiplierWitness)); err != nil {
+		t.Fatalf("reading the witness failed: %v", err)
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness to satisfy the constraints: %v", err)
+	}
+
+	witness[1] = r1cs.Field.NewInt64(16)
+
+	if r1cs.IsSatisfied(witness) == nil {
+		t.Errorf("expected a wrong product not to satisfy the constraints")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	var err error
+
+	var r1cs *qap.R1CS
+	if r1cs, err = ReadR1CS(bytes.NewReader(multiplier)); err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+
+	var written bytes.Buffer
+	if err = WriteR1CS(&written, r1cs); err != nil {
+		t.Fatalf("writing failed: %v", err)
+	}
+
+	// The public c is written as an input rather than an output, and a and b
+	// as internal wires rather than private inputs, which only changes the
+	// counts of the header.
+	var expected = append([]byte(nil), multiplier...)
+	copy(expected[40:52], []byte{0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0})
+
+	if !bytes.Equal(written.Bytes(), expected) {
+		t.Errorf("written file\n%v\nexpected\n%v", written.Bytes(), expected)
+	}
+
+	var witness []*field.Element
+	if witness, err = ReadWitness(bytes.NewReader(multiplierWitness)); err != nil {
+		t.Fatalf("reading the witness failed: %v", err)
+	}
+
+	written.Reset()
+
+	if err = WriteWitness(&written, r1cs, witness); err != nil {
+		t.Fatalf("writing the witness failed: %v", err)
+	}
+
+	if !bytes.Equal(written.Bytes(), multiplierWitness) {
+		t.Errorf("written witness\n%v\nexpected\n%v", written.Bytes(), multiplierWitness)
+	}
+}
+
+func TestCircuit(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// The public output is added last, so the wires are reordered.
+	var c = snark.NewCircuit(f)
+	var x = c.PrivateInput("x")
+	c.Output("out", c.Add(c.Mul(c.Mul(x, x), x), x, c.Constant(5)))
+
+	var witness []*field.Element
+	if witness, err = c.Solve(map[string]*field.Element{"x": f.NewInt64(3)}); err != nil {
+		t.Fatalf("solving failed: %v", err)
+	}
+
+	var file, values bytes.Buffer
+
+	if err = WriteR1CS(&file, c.R1CS()); err != nil {
+		t.Fatalf("writing failed: %v", err)
+	}
+
+	if err = WriteWitness(&values, c.R1CS(), witness); err != nil {
+		t.Fatalf("writing the witness failed: %v", err)
+	}
+
+	var r1cs *qap.R1CS
+	if r1cs, err = ReadR1CS(&file); err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+
+	if witness, err = ReadWitness(&values); err != nil {
+		t.Fatalf("reading the witness failed: %v", err)
+	}
+
+	if len(r1cs.Constraints) != len(c.R1CS().Constraints) {
+		t.Errorf("constraints = %d, expected %d", len(r1cs.Constraints), len(c.R1CS().Constraints))
+	}
+
+	if err = r1cs.IsSatisfied(witness); err != nil {
+		t.Errorf("expected the witness to satisfy the constraints: %v", err)
+	}
+
+	if !witness[1].Equal(f.NewInt64(35)) {
+		t.Errorf("first public wire = %s, expected the output 35", witness[1])
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+
+	var err error
+
+	if _, err = ReadR1CS(bytes.NewReader(multiplierWitness)); err != ErrFormat {
+		t.Errorf("reading a witness as a r1cs: %v, expected %v", err, ErrFormat)
+	}
+
+	if _, err = ReadR1CS(bytes.NewReader(multiplier[:len(multiplier)-40])); err != ErrFormat {
+		t.Errorf("reading a truncated file: %v, expected %v", err, ErrFormat)
+	}
+
+	var unreduced = append([]byte(nil), multiplierWitness...)
+	unreduced[len(unreduced)-8] = 23
+
+	if _, err = ReadWitness(bytes.NewReader(unreduced)); err != ErrNotReduced {
+		t.Errorf("reading an unreduced value: %v, expected %v", err, ErrNotReduced)
+	}
+}
+
+func TestReadBounds(t *testing.T) {
+
+	var err error
+
+	var tests = []struct {
+		name   string
+		file   []byte
+		offset int
+		value  []byte
+		read   func([]byte) error
+	}{
+		// The header counts are only trusted once the sections can hold them.
+		{"witness count", multiplierWitness, 36, []byte{0xff, 0xff, 0xff, 0x7f}, readWitness},
+		{"wire count", multiplier, 36, []byte{0xff, 0xff, 0xff, 0x7f}, readR1CS},
+		{"constraint count", multiplier, 60, []byte{0xff, 0xff, 0xff, 0x7f}, readR1CS},
+
+		// The prime must be at least 3 and match the size of the elements.
+		{"witness prime 1", multiplierWitness, 28, []byte{1}, readWitness},
+		{"r1cs prime 1", multiplier, 28, []byte{1}, readR1CS},
+		{"even prime", multiplier, 28, []byte{22}, readR1CS},
+		{"prime of 16 bytes", multiplierWitness, 24, []byte{16}, readWitness},
+	}
+
+	var test struct {
+		name   string
+		file   []byte
+		offset int
+		value  []byte
+		read   func([]byte) error
+	}
+
+	for _, test = range tests {
+
+		var file = append([]byte(nil), test.file...)
+		copy(file[test.offset:], test.value)
+
+		if err = test.read(file); err != ErrFormat {
+			t.Errorf("%s: %v, expected %v", test.name, err, ErrFormat)
+		}
+	}
+
+	var labels = append([]byte(nil), multiplier[:len(multiplier)-44]...)
+	labels[8] = 2
+
+	if err = readR1CS(labels); err != ErrFormat {
+		t.Errorf("missing labels: %v, expected %v", err, ErrFormat)
+	}
+}
+
+func readR1CS(b []byte) error {
+
+	var _, err = ReadR1CS(bytes.NewReader(b))
+
+	return err
+}
+
+func readWitness(b []byte) error {
+
+	var _, err = ReadWitness(bytes.NewReader(b))
+
+	return err
+}
@@ -0,0 +1,272 @@
+// Package groth16 implements the proof system of Groth (eprint 2016/260) on
+// top of the QAPs of package qap. Proofs are three group elements over bn256
+// and are checked with a single pairing product equation.
+package groth16
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// ErrField is returned when the QAP is not defined over the scalar field of
+// the bn256 groups, so its values can not be used as exponents.
+var ErrField = errors.New("qap is not defined over the order of bn256")
+
+// ProvingKey holds the encodings of the QAP at the secret point x that the
+// prover combines with the witness. The polynomials V, W and Y of the qap
+// package are u, v and w in the notation of the paper.
+type ProvingKey struct {
+	QAP *qap.QAP
+
+	Alpha  *bn256.G1
+	Beta1  *bn256.G1
+	Beta2  *bn256.G2
+	Delta1 *bn256.G1
+	Delta2 *bn256.G2
+
+	// A[i] = [u_i(x)]1, B1[i] = [v_i(x)]1 and B2[i] = [v_i(x)]2 for every
+	// variable.
+	A  []*bn256.G1
+	B1 []*bn256.G1
+	B2 []*bn256.G2
+
+	// K[i] = [(beta * u_i(x) + alpha * v_i(x) + w_i(x)) / delta]1 for the
+	// private variables, and nil for the public ones.
+	K []*bn256.G1
+
+	// H[i] = [x^i * t(x) / delta]1 for evaluating h(x) * t(x) in the exponent.
+	H []*bn256.G1
+}
+
+// VerifyingKey holds the encodings needed to check a proof against the public
+// inputs.
+type VerifyingKey struct {
+	Public []int
+
+	Alpha *bn256.G1
+	Beta  *bn256.G2
+	Gamma *bn256.G2
+	Delta *bn256.G2
+
+	// AlphaBeta is e(alpha, beta), which is the same for every proof.
+	AlphaBeta *bn256.GT
+
+	// IC[j] = [(beta * u_i(x) + alpha * v_i(x) + w_i(x)) / gamma]1 for the
+	// public variable i = Public[j].
+	IC []*bn256.G1
+}
+
+// Proof is the triple (A, B, C) of the paper.
+type Proof struct {
+	A *bn256.G1
+	B *bn256.G2
+	C *bn256.G1
+}
+
+// Setup samples the trapdoor alpha, beta, gamma, delta and x, and encodes the
+// QAP with it. The trapdoor is discarded once the keys are derived.
+func Setup(q *qap.QAP) (*ProvingKey, *VerifyingKey, error) {
+
+	var err error
+
+	var f = q.Field
+
+	if f.Modulus().Cmp(bn256.Order) != 0 {
+		return nil, nil, ErrField
+	}
+
+	var trapdoor = make([]*field.Element, 5)
+
+	var i int
+	for i = range trapdoor {
+		if trapdoor[i], err = f.Rand(rand.Reader); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var alpha, beta, gamma, delta, x = trapdoor[0], trapdoor[1], trapdoor[2], trapdoor[3], trapdoor[4]
+
+	var gammaInv = new(field.Element).Inv(gamma)
+	var deltaInv = new(field.Element).Inv(delta)
+
+	// Resample in the negligible case that gamma or delta is zero.
+	if gammaInv == nil || deltaInv == nil {
+		return Setup(q)
+	}
+
+	var us, vs, ws, t = q.Evaluate(x)
+
+	var public = make(map[int]bool)
+
+	var k int
+	for _, k = range q.Public {
+		public[k] = true
+	}
+
+	var pk = &ProvingKey{
+		QAP:    q,
+		Alpha:  g1(alpha),
+		Beta1:  g1(beta),
+		Beta2:  g2(beta),
+		Delta1: g1(delta),
+		Delta2: g2(delta),
+		A:      make([]*bn256.G1, len(us)),
+		B1:     make([]*bn256.G1, len(vs)),
+		B2:     make([]*bn256.G2, len(vs)),
+		K:      make([]*bn256.G1, len(us)),
+	}
+
+	var vk = &VerifyingKey{
+		Public: q.Public,
+		Alpha:  g1(alpha),
+		Beta:   g2(beta),
+		Gamma:  g2(gamma),
+		Delta:  g2(delta),
+	}
+
+	vk.AlphaBeta = bn256.Pair(vk.Alpha, vk.Beta)
+
+	for k = range us {
+
+		pk.A[k] = g1(us[k])
+		pk.B1[k] = g1(vs[k])
+		pk.B2[k] = g2(vs[k])
+
+		// beta * u_k(x) + alpha * v_k(x) + w_k(x)
+		var combined = new(field.Element).Mul(beta, us[k])
+		combined.Add(combined, new(field.Element).Mul(alpha, vs[k]))
+		combined.Add(combined, ws[k])
+
+		if public[k] {
+			vk.IC = append(vk.IC, g1(combined.Mul(combined, gammaInv)))
+			continue
+		}
+
+		pk.K[k] = g1(combined.Mul(combined, deltaInv))
+	}
+
+	// h(x) has degree at most deg t(x) - 2.
+	var powers = q.T.Degree() - 1
+	if powers < 0 {
+		powers = 0
+	}
+
+	pk.H = make([]*bn256.G1, powers)
+
+	var power = new(field.Element).Mul(t, deltaInv)
+	for i = range pk.H {
+		pk.H[i] = g1(power)
+		power.Mul(power, x)
+	}
+
+	return pk, vk, nil
+}
+
+// Prove computes a proof for a witness of the R1CS the QAP was compiled from.
+// The random r and s blind A, B and C so the proof reveals nothing about the
+// private variables. A witness without one value for every variable is
+// rejected with qap.ErrWitnessLength, one that does not start with 1 with
+// qap.ErrWitnessConstant, and one that does not satisfy the constraints with
+// qap.ErrNotDivisible.
+func Prove(pk *ProvingKey, witness []*field.Element) (*Proof, error) {
+
+	var err error
+
+	var f = pk.QAP.Field
+
+	if err = pk.QAP.Check(witness); err != nil {
+		return nil, err
+	}
+
+	var h *qap.Polynomial
+	if h, err = pk.QAP.Quotient(witness); err != nil {
+		return nil, err
+	}
+
+	var r, s *field.Element
+
+	if r, err = f.Rand(rand.Reader); err != nil {
+		return nil, err
+	}
+
+	if s, err = f.Rand(rand.Reader); err != nil {
+		return nil, err
+	}
+
+	// A = alpha + sum a_i * u_i(x) + r * delta
+	var a = new(bn256.G1).Add(pk.Alpha, new(bn256.G1).ScalarMult(pk.Delta1, r.Big()))
+
+	// B = beta + sum a_i * v_i(x) + s * delta
+	var b2 = new(bn256.G2).Add(pk.Beta2, new(bn256.G2).ScalarMult(pk.Delta2, s.Big()))
+	var b1 = new(bn256.G1).Add(pk.Beta1, new(bn256.G1).ScalarMult(pk.Delta1, s.Big()))
+
+	var c = new(bn256.G1).ScalarBaseMult(f.Zero().Big())
+
+	var k int
+	for k = range witness {
+
+		var value = witness[k].Big()
+
+		a.Add(a, new(bn256.G1).ScalarMult(pk.A[k], value))
+		b1.Add(b1, new(bn256.G1).ScalarMult(pk.B1[k], value))
+		b2.Add(b2, new(bn256.G2).ScalarMult(pk.B2[k], value))
+
+		if pk.K[k] != nil {
+			c.Add(c, new(bn256.G1).ScalarMult(pk.K[k], value))
+		}
+	}
+
+	var i int
+	var coeff *field.Element
+
+	for i, coeff = range h.Coefficients() {
+		c.Add(c, new(bn256.G1).ScalarMult(pk.H[i], coeff.Big()))
+	}
+
+	// C = ... + s * A + r * B - r * s * delta
+	var rs = new(field.Element).Mul(r, s)
+
+	c.Add(c, new(bn256.G1).ScalarMult(a, s.Big()))
+	c.Add(c, new(bn256.G1).ScalarMult(b1, r.Big()))
+	c.Add(c, new(bn256.G1).ScalarMult(pk.Delta1, rs.Neg(rs).Big()))
+
+	return &Proof{A: a, B: b2, C: c}, nil
+}
+
+// Verify checks e(A, B) = e(alpha, beta) * e(IC, gamma) * e(C, delta), where
+// IC combines the verifying key with the values of the public variables. The
+// inputs are in the order of VerifyingKey.Public without the constant at
+// index 0.
+func Verify(vk *VerifyingKey, publicInputs []*field.Element, proof *Proof) bool {
+
+	if len(publicInputs) != len(vk.Public)-1 {
+		return false
+	}
+
+	var ic = new(bn256.G1).Set(vk.IC[0])
+
+	var j int
+	var input *field.Element
+
+	for j, input = range publicInputs {
+		ic.Add(ic, new(bn256.G1).ScalarMult(vk.IC[j+1], input.Big()))
+	}
+
+	var right = new(bn256.GT).Add(vk.AlphaBeta, bn256.Pair(ic, vk.Gamma))
+	right.Add(right, bn256.Pair(proof.C, vk.Delta))
+
+	return bytes.Equal(bn256.Pair(proof.A, proof.B).Marshal(), right.Marshal())
+}
+
+func g1(x *field.Element) *bn256.G1 {
+	return new(bn256.G1).ScalarBaseMult(x.Big())
+}
+
+func g2(x *field.Element) *bn256.G2 {
+	return new(bn256.G2).ScalarBaseMult(x.Big())
+}
@@ -0,0 +1,151 @@
+package groth16
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// cubic returns the constraints of x^3 + x + 5 = out from qap.Cubic, with
+// x = 3 private and out = 35 public, compiled at random roots or over a
+// domain with qap.CompileNTT.
+func cubic(t *testing.T, ntt bool) (*qap.QAP, []*field.Element) {
+
+	var err error
+
+	var r1cs, witness = qap.Cubic(field.New(bn256.Order))
+
+	var q *qap.QAP
+
+	if ntt {
+
+		if q, err = qap.CompileNTT(r1cs); err != nil {
+			t.Fatalf("compilation failed: %v", err)
+		}
+
+		return q, witness
+	}
+
+	var roots = make([]*field.Element, len(r1cs.Constraints))
+
+	var i int
+	for i = range roots {
+		if roots[i], err = r1cs.Field.Rand(rand.Reader); err != nil {
+			t.Fatalf("sampling failed: %v", err)
+		}
+	}
+
+	if q, err = qap.Compile(r1cs, roots); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	return q, witness
+}
+
+func TestGroth16(t *testing.T) {
+
+	var err error
+
+	var q, witness = cubic(t, false)
+
+	var pk *ProvingKey
+	var vk *VerifyingKey
+
+	if pk, vk, err = Setup(q); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	var first, second *Proof
+
+	if first, err = Prove(pk, witness); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if second, err = Prove(pk, witness); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	var inputs = []*field.Element{q.Field.NewInt64(35)}
+
+	if !Verify(vk, inputs, first) || !Verify(vk, inputs, second) {
+		t.Errorf("expected both proofs to verify")
+	}
+
+	// The blinding factors r and s make every proof of the same statement
+	// different.
+	if bytes.Equal(first.A.Marshal(), second.A.Marshal()) {
+		t.Errorf("expected proofs with independent blinding")
+	}
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(36)}, first) {
+		t.Errorf("expected the proof not to verify for a different output")
+	}
+
+	if Verify(vk, nil, first) {
+		t.Errorf("expected the proof not to verify without the public inputs")
+	}
+
+	var forged = &Proof{A: first.A, B: first.B, C: second.C}
+
+	if Verify(vk, inputs, forged) {
+		t.Errorf("expected a proof mixing two proofs not to verify")
+	}
+
+	witness[4] = q.Field.NewInt64(36)
+
+	if _, err = Prove(pk, witness); err != qap.ErrNotDivisible {
+		t.Errorf("expected %v, got %v", qap.ErrNotDivisible, err)
+	}
+}
+
+func TestGroth16NTT(t *testing.T) {
+
+	var err error
+
+	var q, witness = cubic(t, true)
+
+	var pk *ProvingKey
+	var vk *VerifyingKey
+
+	if pk, vk, err = Setup(q); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	var proof *Proof
+	if proof, err = Prove(pk, witness); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if !Verify(vk, []*field.Element{q.Field.NewInt64(35)}, proof) {
+		t.Errorf("expected the proof to verify")
+	}
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(36)}, proof) {
+		t.Errorf("expected the proof not to verify for a different output")
+	}
+
+	if _, err = Prove(pk, witness[:4]); err != qap.ErrWitnessLength {
+		t.Errorf("expected %v for a short witness, got %v", qap.ErrWitnessLength, err)
+	}
+
+	if _, err = Prove(pk, append(witness, q.Field.One())); err != qap.ErrWitnessLength {
+		t.Errorf("expected %v for a long witness, got %v", qap.ErrWitnessLength, err)
+	}
+
+	witness[0] = q.Field.NewInt64(2)
+
+	if _, err = Prove(pk, witness); err != qap.ErrWitnessConstant {
+		t.Errorf("expected %v, got %v", qap.ErrWitnessConstant, err)
+	}
+
+	witness[0] = q.Field.One()
+	witness[4] = q.Field.NewInt64(36)
+
+	if _, err = Prove(pk, witness); err != qap.ErrNotDivisible {
+		t.Errorf("expected %v, got %v", qap.ErrNotDivisible, err)
+	}
+}
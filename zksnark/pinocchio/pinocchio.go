@@ -0,0 +1,389 @@
+// Package pinocchio implements the Pinocchio proof system (eprint 2013/279)
+// on top of the QAPs of package qap. The keys follow the asymmetric variant
+// over bn256: the encodings of v(s) and y(s) live in G1, those of w(s) and of
+// the quotient h(s) in G2, and the verifier only needs the verification key,
+// the public inputs and the eight group elements of a proof.
+package pinocchio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// ErrField is returned when the QAP is not defined over the scalar field of
+// the bn256 groups, so its values can not be used as exponents.
+var ErrField = errors.New("qap is not defined over the order of bn256")
+
+// EvaluationKey is published to the prover. Each slice has one encoding per
+// variable of the QAP, and the entries of the public variables are nil since
+// the verifier adds those terms itself.
+type EvaluationKey struct {
+	QAP *qap.QAP
+
+	// V[k] = g1^(rv * v_k(s)), W[k] = g2^(rw * w_k(s)), Y[k] = g1^(ry * y_k(s))
+	V []*bn256.G1
+	W []*bn256.G2
+	Y []*bn256.G1
+
+	// The same encodings multiplied by alpha_v, alpha_w and alpha_y, which
+	// prove the prover only used linear combinations of the key.
+	AlphaV []*bn256.G1
+	AlphaW []*bn256.G1
+	AlphaY []*bn256.G1
+
+	// Beta[k] = g1^(beta * (rv * v_k(s) + rw * w_k(s) + ry * y_k(s))) checks
+	// the same coefficients were used for V, W and Y.
+	Beta []*bn256.G1
+
+	// Encodings of t(s) in place of v_k(s), w_k(s) and y_k(s), which the prover
+	// uses to add random multiples of t(x) for zero-knowledge.
+	TV, TAlphaV, TBetaV *bn256.G1
+	TW                  *bn256.G2
+	TAlphaW, TBetaW     *bn256.G1
+	TY, TAlphaY, TBetaY *bn256.G1
+
+	// Powers[i] = g2^(s^i) for evaluating h(s) in the exponent.
+	Powers []*bn256.G2
+}
+
+// VerificationKey is published to anyone checking proofs.
+type VerificationKey struct {
+	Public []int
+
+	AlphaV *bn256.G2
+	AlphaW *bn256.G1
+	AlphaY *bn256.G2
+
+	Gamma     *bn256.G2
+	BetaGamma *bn256.G2
+
+	// BetaGamma1 is the same value as BetaGamma in G1, for pairing with the
+	// encoding of w(s).
+	BetaGamma1 *bn256.G1
+
+	// T = g1^(ry * t(s))
+	T *bn256.G1
+
+	// Encodings of v_k(s), w_k(s) and y_k(s) for the public variables, in the
+	// order of Public.
+	V []*bn256.G1
+	W []*bn256.G2
+	Y []*bn256.G1
+}
+
+// Proof holds the encodings of the private part of v(s), w(s) and y(s), their
+// alpha and beta shifted copies, and h(s).
+type Proof struct {
+	V      *bn256.G1
+	W      *bn256.G2
+	Y      *bn256.G1
+	AlphaV *bn256.G1
+	AlphaW *bn256.G1
+	AlphaY *bn256.G1
+	Beta   *bn256.G1
+	H      *bn256.G2
+}
+
+// GenerateKeys samples the secret point s and the trapdoors rv, rw, alpha_v,
+// alpha_w, alpha_y, beta and gamma, and encodes the QAP with them. The
+// trapdoors are discarded once the keys are derived.
+func GenerateKeys(q *qap.QAP) (*EvaluationKey, *VerificationKey, error) {
+
+	var err error
+
+	var f = q.Field
+
+	if f.Modulus().Cmp(bn256.Order) != 0 {
+		return nil, nil, ErrField
+	}
+
+	var trapdoors = make([]*field.Element, 7)
+
+	var i int
+	for i = range trapdoors {
+		if trapdoors[i], err = f.Rand(rand.Reader); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var rv, rw, alphaV, alphaW, alphaY, beta, gamma = trapdoors[0], trapdoors[1], trapdoors[2],
+		trapdoors[3], trapdoors[4], trapdoors[5], trapdoors[6]
+
+	var ry = new(field.Element).Mul(rv, rw)
+
+	var s *field.Element
+	if s, err = f.Rand(rand.Reader); err != nil {
+		return nil, nil, err
+	}
+
+	var vs, ws, ys, t = q.Evaluate(s)
+
+	var public = make(map[int]bool)
+
+	var k int
+	for _, k = range q.Public {
+		public[k] = true
+	}
+
+	var ek = &EvaluationKey{
+		QAP:    q,
+		V:      make([]*bn256.G1, len(vs)),
+		W:      make([]*bn256.G2, len(ws)),
+		Y:      make([]*bn256.G1, len(ys)),
+		AlphaV: make([]*bn256.G1, len(vs)),
+		AlphaW: make([]*bn256.G1, len(ws)),
+		AlphaY: make([]*bn256.G1, len(ys)),
+		Beta:   make([]*bn256.G1, len(vs)),
+	}
+
+	var vk = &VerificationKey{
+		Public:     q.Public,
+		AlphaV:     g2(alphaV),
+		AlphaW:     g1(alphaW),
+		AlphaY:     g2(alphaY),
+		Gamma:      g2(gamma),
+		BetaGamma:  g2(new(field.Element).Mul(beta, gamma)),
+		BetaGamma1: g1(new(field.Element).Mul(beta, gamma)),
+		T:          g1(new(field.Element).Mul(ry, t)),
+	}
+
+	for k = range vs {
+
+		var v = new(field.Element).Mul(rv, vs[k])
+		var w = new(field.Element).Mul(rw, ws[k])
+		var y = new(field.Element).Mul(ry, ys[k])
+
+		if public[k] {
+			vk.V = append(vk.V, g1(v))
+			vk.W = append(vk.W, g2(w))
+			vk.Y = append(vk.Y, g1(y))
+			continue
+		}
+
+		ek.V[k], ek.W[k], ek.Y[k] = g1(v), g2(w), g1(y)
+
+		ek.AlphaV[k] = g1(new(field.Element).Mul(alphaV, v))
+		ek.AlphaW[k] = g1(new(field.Element).Mul(alphaW, w))
+		ek.AlphaY[k] = g1(new(field.Element).Mul(alphaY, y))
+
+		ek.Beta[k] = g1(new(field.Element).Mul(beta, sum(v, w, y)))
+	}
+
+	var tv = new(field.Element).Mul(rv, t)
+	var tw = new(field.Element).Mul(rw, t)
+	var ty = new(field.Element).Mul(ry, t)
+
+	ek.TV, ek.TW, ek.TY = g1(tv), g2(tw), g1(ty)
+
+	ek.TAlphaV = g1(new(field.Element).Mul(alphaV, tv))
+	ek.TAlphaW = g1(new(field.Element).Mul(alphaW, tw))
+	ek.TAlphaY = g1(new(field.Element).Mul(alphaY, ty))
+
+	ek.TBetaV = g1(new(field.Element).Mul(beta, tv))
+	ek.TBetaW = g1(new(field.Element).Mul(beta, tw))
+	ek.TBetaY = g1(new(field.Element).Mul(beta, ty))
+
+	// h(x) + delta_w * v(x) + delta_v * w(x) + ... has degree at most deg t(x).
+	ek.Powers = make([]*bn256.G2, q.T.Degree()+1)
+
+	var power = f.One()
+	for i = range ek.Powers {
+		ek.Powers[i] = g2(power)
+		power.Mul(power, s)
+	}
+
+	return ek, vk, nil
+}
+
+// Prove computes a proof for a witness of the R1CS the QAP was compiled
+// from. The private part of v(x), w(x) and y(x) is randomized with multiples
+// of t(x), so the proof reveals nothing about the private variables. A witness
+// without one value for every variable is rejected with qap.ErrWitnessLength,
+// one that does not start with 1 with qap.ErrWitnessConstant, and one that
+// does not satisfy the constraints with qap.ErrNotDivisible.
+func Prove(ek *EvaluationKey, witness []*field.Element) (*Proof, error) {
+
+	var err error
+
+	var q = ek.QAP
+	var f = q.Field
+
+	if err = q.Check(witness); err != nil {
+		return nil, err
+	}
+
+	var h *qap.Polynomial
+	if h, err = q.Quotient(witness); err != nil {
+		return nil, err
+	}
+
+	var deltas = make([]*field.Element, 3)
+
+	var i int
+	for i = range deltas {
+		if deltas[i], err = f.Rand(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+
+	var deltaV, deltaW, deltaY = deltas[0], deltas[1], deltas[2]
+
+	// (v + dv * t) * (w + dw * t) - (y + dy * t)
+	//     = t * (h + dw * v + dv * w + dv * dw * t - dy)
+	var v, w, _ = q.Combine(witness)
+
+	h = h.Add(v.Scale(deltaW)).
+		Add(w.Scale(deltaV)).
+		Add(q.T.Scale(new(field.Element).Mul(deltaV, deltaW))).
+		Sub(qap.NewPolynomial(f, deltaY))
+
+	var proof = &Proof{
+		V:      new(bn256.G1).ScalarMult(ek.TV, deltaV.Big()),
+		W:      new(bn256.G2).ScalarMult(ek.TW, deltaW.Big()),
+		Y:      new(bn256.G1).ScalarMult(ek.TY, deltaY.Big()),
+		AlphaV: new(bn256.G1).ScalarMult(ek.TAlphaV, deltaV.Big()),
+		AlphaW: new(bn256.G1).ScalarMult(ek.TAlphaW, deltaW.Big()),
+		AlphaY: new(bn256.G1).ScalarMult(ek.TAlphaY, deltaY.Big()),
+		H:      new(bn256.G2).ScalarMult(ek.Powers[0], f.Zero().Big()),
+	}
+
+	proof.Beta = new(bn256.G1).Add(
+		new(bn256.G1).ScalarMult(ek.TBetaV, deltaV.Big()),
+		new(bn256.G1).Add(
+			new(bn256.G1).ScalarMult(ek.TBetaW, deltaW.Big()),
+			new(bn256.G1).ScalarMult(ek.TBetaY, deltaY.Big()),
+		),
+	)
+
+	var k int
+	for k = range witness {
+
+		if ek.V[k] == nil {
+			continue
+		}
+
+		var a = witness[k].Big()
+
+		proof.V.Add(proof.V, new(bn256.G1).ScalarMult(ek.V[k], a))
+		proof.W.Add(proof.W, new(bn256.G2).ScalarMult(ek.W[k], a))
+		proof.Y.Add(proof.Y, new(bn256.G1).ScalarMult(ek.Y[k], a))
+
+		proof.AlphaV.Add(proof.AlphaV, new(bn256.G1).ScalarMult(ek.AlphaV[k], a))
+		proof.AlphaW.Add(proof.AlphaW, new(bn256.G1).ScalarMult(ek.AlphaW[k], a))
+		proof.AlphaY.Add(proof.AlphaY, new(bn256.G1).ScalarMult(ek.AlphaY[k], a))
+
+		proof.Beta.Add(proof.Beta, new(bn256.G1).ScalarMult(ek.Beta[k], a))
+	}
+
+	var coeff *field.Element
+	for i, coeff = range h.Coefficients() {
+		proof.H.Add(proof.H, new(bn256.G2).ScalarMult(ek.Powers[i], coeff.Big()))
+	}
+
+	return proof, nil
+}
+
+// Verify checks a proof against the values of the public variables, in the
+// order of VerificationKey.Public without the constant at index 0. It
+// validates the alpha and beta terms and the divisibility check
+// e(V, W) = e(T, H) * e(Y, g2) in the exponent.
+func Verify(vk *VerificationKey, inputs []*field.Element, proof *Proof) bool {
+
+	if len(inputs) != len(vk.Public)-1 {
+		return false
+	}
+
+	// The private terms are linear combinations of the evaluation key.
+
+	if !equal(
+		bn256.Pair(proof.AlphaV, g2(nil)),
+		bn256.Pair(proof.V, vk.AlphaV),
+	) {
+		return false
+	}
+
+	if !equal(
+		bn256.Pair(proof.AlphaW, g2(nil)),
+		bn256.Pair(vk.AlphaW, proof.W),
+	) {
+		return false
+	}
+
+	if !equal(
+		bn256.Pair(proof.AlphaY, g2(nil)),
+		bn256.Pair(proof.Y, vk.AlphaY),
+	) {
+		return false
+	}
+
+	// The same coefficients were used in V, W and Y.
+
+	if !equal(
+		bn256.Pair(proof.Beta, vk.Gamma),
+		new(bn256.GT).Add(
+			bn256.Pair(new(bn256.G1).Add(proof.V, proof.Y), vk.BetaGamma),
+			bn256.Pair(vk.BetaGamma1, proof.W),
+		),
+	) {
+		return false
+	}
+
+	// Add the public terms, starting with the constant 1.
+
+	var v = new(bn256.G1).Add(proof.V, vk.V[0])
+	var w = new(bn256.G2).Add(proof.W, vk.W[0])
+	var y = new(bn256.G1).Add(proof.Y, vk.Y[0])
+
+	var i int
+	var input *field.Element
+
+	for i, input = range inputs {
+
+		var a = input.Big()
+
+		v.Add(v, new(bn256.G1).ScalarMult(vk.V[i+1], a))
+		w.Add(w, new(bn256.G2).ScalarMult(vk.W[i+1], a))
+		y.Add(y, new(bn256.G1).ScalarMult(vk.Y[i+1], a))
+	}
+
+	return equal(
+		bn256.Pair(v, w),
+		new(bn256.GT).Add(bn256.Pair(vk.T, proof.H), bn256.Pair(y, g2(nil))),
+	)
+}
+
+// g1 encodes a scalar in G1 with the standard generator, or returns the
+// generator itself for nil.
+func g1(x *field.Element) *bn256.G1 {
+
+	if x == nil {
+		return new(bn256.G1).ScalarBaseMult(big.NewInt(1))
+	}
+
+	return new(bn256.G1).ScalarBaseMult(x.Big())
+}
+
+// g2 encodes a scalar in G2 with the standard generator, or returns the
+// generator itself for nil.
+func g2(x *field.Element) *bn256.G2 {
+
+	if x == nil {
+		return new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	}
+
+	return new(bn256.G2).ScalarBaseMult(x.Big())
+}
+
+func sum(x, y, z *field.Element) *field.Element {
+	return new(field.Element).Add(x, new(field.Element).Add(y, z))
+}
+
+func equal(a, b *bn256.GT) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
@@ -0,0 +1,164 @@
+package pinocchio
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+	"github.com/eugenekadish/cryptopalooza/zksnark/qap"
+)
+
+// cubic returns the constraints of x^3 + x + 5 = out from qap.Cubic, with
+// x = 3 private and out = 35 public, compiled at random roots or over a
+// domain with qap.CompileNTT.
+func cubic(t *testing.T, ntt bool) (*qap.QAP, []*field.Element) {
+
+	var err error
+
+	var r1cs, witness = qap.Cubic(field.New(bn256.Order))
+
+	var q *qap.QAP
+
+	if ntt {
+
+		if q, err = qap.CompileNTT(r1cs); err != nil {
+			t.Fatalf("compilation failed: %v", err)
+		}
+
+		return q, witness
+	}
+
+	var roots = make([]*field.Element, len(r1cs.Constraints))
+
+	var i int
+	for i = range roots {
+		if roots[i], err = r1cs.Field.Rand(rand.Reader); err != nil {
+			t.Fatalf("sampling failed: %v", err)
+		}
+	}
+
+	if q, err = qap.Compile(r1cs, roots); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	return q, witness
+}
+
+func TestPinocchio(t *testing.T) {
+
+	var err error
+
+	var q, witness = cubic(t, false)
+
+	var ek *EvaluationKey
+	var vk *VerificationKey
+
+	if ek, vk, err = GenerateKeys(q); err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+
+	var proof *Proof
+	if proof, err = Prove(ek, witness); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if !Verify(vk, []*field.Element{q.Field.NewInt64(35)}, proof) {
+		t.Errorf("expected the proof to verify")
+	}
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(36)}, proof) {
+		t.Errorf("expected the proof not to verify for a different output")
+	}
+
+	if Verify(vk, nil, proof) {
+		t.Errorf("expected the proof not to verify without the public inputs")
+	}
+
+	// A proof that skips the alpha terms is rejected even though the
+	// divisibility check still holds.
+	var forged = *proof
+	forged.AlphaV = new(bn256.G1).Add(proof.AlphaV, proof.V)
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(35)}, &forged) {
+		t.Errorf("expected a proof with a forged alpha term not to verify")
+	}
+
+	forged = *proof
+	forged.Beta = new(bn256.G1).Add(proof.Beta, proof.Y)
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(35)}, &forged) {
+		t.Errorf("expected a proof with a forged beta term not to verify")
+	}
+
+	witness[4] = q.Field.NewInt64(36)
+
+	if _, err = Prove(ek, witness); err != qap.ErrNotDivisible {
+		t.Errorf("expected %v, got %v", qap.ErrNotDivisible, err)
+	}
+}
+
+func TestPinocchioNTT(t *testing.T) {
+
+	var err error
+
+	var q, witness = cubic(t, true)
+
+	var ek *EvaluationKey
+	var vk *VerificationKey
+
+	if ek, vk, err = GenerateKeys(q); err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+
+	var proof *Proof
+	if proof, err = Prove(ek, witness); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if !Verify(vk, []*field.Element{q.Field.NewInt64(35)}, proof) {
+		t.Errorf("expected the proof to verify")
+	}
+
+	if Verify(vk, []*field.Element{q.Field.NewInt64(36)}, proof) {
+		t.Errorf("expected the proof not to verify for a different output")
+	}
+
+	if _, err = Prove(ek, witness[:4]); err != qap.ErrWitnessLength {
+		t.Errorf("expected %v for a short witness, got %v", qap.ErrWitnessLength, err)
+	}
+
+	if _, err = Prove(ek, append(witness, q.Field.One())); err != qap.ErrWitnessLength {
+		t.Errorf("expected %v for a long witness, got %v", qap.ErrWitnessLength, err)
+	}
+
+	witness[0] = q.Field.NewInt64(2)
+
+	if _, err = Prove(ek, witness); err != qap.ErrWitnessConstant {
+		t.Errorf("expected %v, got %v", qap.ErrWitnessConstant, err)
+	}
+
+	witness[0] = q.Field.One()
+	witness[4] = q.Field.NewInt64(36)
+
+	if _, err = Prove(ek, witness); err != qap.ErrNotDivisible {
+		t.Errorf("expected %v, got %v", qap.ErrNotDivisible, err)
+	}
+}
+
+func TestGenerateKeysField(t *testing.T) {
+
+	var err error
+
+	var f = field.New(big.NewInt(997))
+
+	var q *qap.QAP
+	if q, err = qap.Compile(qap.NewR1CS(f), nil); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	if _, _, err = GenerateKeys(q); err != ErrField {
+		t.Errorf("expected %v, got %v", ErrField, err)
+	}
+}
@@ -0,0 +1,152 @@
+package qap
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// bluesteinRadix is the smallest prime radix transformed with Bluestein's
+// algorithm instead of the quadratic sum.
+const bluesteinRadix = 64
+
+// butterfly transforms vectors of a prime size r with a fixed root of order
+// r, the step of the mixed radix NTT for one prime factor of the domain.
+type butterfly struct {
+	field  *field.Field
+	powers []*field.Element
+
+	// chirp and filter are only set for Bluestein's algorithm.
+	chirp  []*field.Element
+	filter []big.Word
+	slot   int
+}
+
+// newButterfly precomputes the powers of the root, and for a large radix the
+// chirp of Bluestein's algorithm. With h = (r + 1) / 2 the inverse of 2
+// modulo the odd r,
+//
+//	t * q = h * (t^2 + q^2 - (q - t)^2)  mod r
+//
+// so the transform is X[q] = c[q] * sum_t (z[t] * c[t]) / c[q - t] for the
+// chirp c[k] = root^(h * k^2), a convolution that is computed as a single
+// product of big integers.
+func newButterfly(f *field.Field, r int, root *field.Element) *butterfly {
+
+	var b = &butterfly{field: f, powers: make([]*field.Element, r)}
+
+	b.powers[0] = f.One()
+
+	var k int
+	for k = 1; k < r; k++ {
+		b.powers[k] = new(field.Element).Mul(b.powers[k-1], root)
+	}
+
+	if r < bluesteinRadix {
+		return b
+	}
+
+	var h = (r + 1) / 2
+
+	b.chirp = make([]*field.Element, r)
+
+	for k = range b.chirp {
+		b.chirp[k] = b.powers[h*k%r*k%r]
+	}
+
+	// The filter is 1 / c[m - (r - 1)] for m < 2r - 1.
+	var filter = make([]*field.Element, 2*r-1)
+
+	for k = range filter {
+		var d = k - (r - 1)
+		if d < 0 {
+			d = -d
+		}
+		filter[k] = b.powers[(r-h*d%r*d%r)%r]
+	}
+
+	b.slot = (2*f.Modulus().BitLen() + bits.Len(uint(r)) + bits.UintSize) / bits.UintSize
+	b.filter = pack(filter, b.slot)
+
+	return b
+}
+
+// transform returns X[q] = sum_t z[t] * root^(t * q).
+func (b *butterfly) transform(z []*field.Element) []*field.Element {
+
+	var r = len(b.powers)
+	var x = make([]*field.Element, r)
+
+	if r == 2 {
+		x[0] = new(field.Element).Add(z[0], z[1])
+		x[1] = new(field.Element).Sub(z[0], z[1])
+
+		return x
+	}
+
+	if b.chirp != nil {
+		return b.bluestein(z)
+	}
+
+	var t, q int
+	for q = range x {
+
+		x[q] = b.field.Zero()
+
+		for t = range z {
+			x[q].Add(x[q], new(field.Element).Mul(z[t], b.powers[t*q%r]))
+		}
+	}
+
+	return x
+}
+
+// bluestein computes the transform as a convolution with the filter, by
+// Kronecker substitution: the coefficients are packed into big integers with
+// slots wide enough that the sums of products never carry into the next one.
+func (b *butterfly) bluestein(z []*field.Element) []*field.Element {
+
+	var r = len(b.powers)
+
+	var a = make([]*field.Element, r)
+
+	var k int
+	for k = range a {
+		a[k] = new(field.Element).Mul(z[k], b.chirp[k])
+	}
+
+	var product = new(big.Int).Mul(new(big.Int).SetBits(pack(a, b.slot)), new(big.Int).SetBits(b.filter))
+	var words = product.Bits()
+
+	var x = make([]*field.Element, r)
+
+	for k = range x {
+
+		var slot = make([]big.Word, b.slot)
+
+		var lo = (k + r - 1) * b.slot
+		if lo < len(words) {
+			copy(slot, words[lo:])
+		}
+
+		x[k] = b.field.NewElement(new(big.Int).SetBits(slot))
+		x[k].Mul(x[k], b.chirp[k])
+	}
+
+	return x
+}
+
+// pack lays out the values in consecutive slots of the given number of
+// words, the first value in the lowest.
+func pack(values []*field.Element, slot int) []big.Word {
+
+	var words = make([]big.Word, len(values)*slot)
+
+	var k int
+	for k = range values {
+		copy(words[k*slot:(k+1)*slot], values[k].Big().Bits())
+	}
+
+	return words
+}
@@ -0,0 +1,470 @@
+package qap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// ErrRootCount is returned when the number of roots does not match the number
+// of constraints being compiled.
+var ErrRootCount = errors.New("number of roots does not match the number of constraints")
+
+// ErrDuplicateRoot is returned when two roots are the same element of the
+// field, so the Lagrange basis is not defined.
+var ErrDuplicateRoot = errors.New("roots must be distinct")
+
+// ErrNotDivisible is returned when v(x) * w(x) - y(x) has a non-zero remainder
+// modulo t(x), which means the witness does not satisfy the constraints.
+var ErrNotDivisible = errors.New("target polynomial does not divide v(x) * w(x) - y(x)")
+
+// QAP is a quadratic arithmetic program. Each variable i of the R1CS it was
+// compiled from has the polynomials V[i], W[i] and Y[i] which interpolate
+// column i of the A, B and C matrices at the roots, and T is the target
+// polynomial that vanishes on the roots. A QAP from CompileNTT leaves them
+// nil, and works from the R1CS and the Domain instead.
+type QAP struct {
+	Field *field.Field
+	Roots []*field.Element
+
+	// Public lists the indices of the variables known to the verifier, as
+	// returned by R1CS.Public, starting with the constant at index 0.
+	Public []int
+
+	V []*Polynomial
+	W []*Polynomial
+	Y []*Polynomial
+
+	T *Polynomial
+
+	// Domain and R1CS are set by CompileNTT, whose roots are the points of
+	// the domain.
+	Domain *Domain
+	R1CS   *R1CS
+}
+
+// Compile derives the QAP of a R1CS, where constraint j is enforced at
+// roots[j]. This replaces the hand written interpolation in the examples: the
+// Lagrange basis for the roots is computed once in coefficient form and every
+// non-zero matrix entry adds a multiple of it to the polynomial of its column.
+func Compile(r1cs *R1CS, roots []*field.Element) (*QAP, error) {
+
+	var err error
+
+	if len(roots) != len(r1cs.Constraints) {
+		return nil, ErrRootCount
+	}
+
+	var f = r1cs.Field
+
+	var i, j int
+	for i = range roots {
+		for j = i + 1; j < len(roots); j++ {
+			if roots[i].Equal(roots[j]) {
+				return nil, ErrDuplicateRoot
+			}
+		}
+	}
+
+	var t = Vanishing(f, roots...)
+
+	var basis = make([]*Polynomial, len(roots))
+
+	var root *field.Element
+	for j, root = range roots {
+
+		// l_{j}(x) = t(x) / (x - r_{j}) / prod_{k != j} (r_{j} - r_{k})
+
+		var denominator = f.One()
+
+		var k int
+		for k = range roots {
+			if k != j {
+				denominator.Mul(denominator, new(field.Element).Sub(root, roots[k]))
+			}
+		}
+
+		var numerator *Polynomial
+		if numerator, _, err = t.DivMod(Vanishing(f, root)); err != nil {
+			return nil, err
+		}
+
+		basis[j] = numerator.Scale(denominator.Inv(denominator))
+	}
+
+	var q = &QAP{
+		Field:  f,
+		Roots:  roots,
+		Public: r1cs.Public(),
+		V:      zeroPolynomials(f, len(r1cs.Variables)),
+		W:      zeroPolynomials(f, len(r1cs.Variables)),
+		Y:      zeroPolynomials(f, len(r1cs.Variables)),
+		T:      t,
+	}
+
+	var constraint Constraint
+	for j, constraint = range r1cs.Constraints {
+		accumulate(q.V, constraint.A, basis[j])
+		accumulate(q.W, constraint.B, basis[j])
+		accumulate(q.Y, constraint.C, basis[j])
+	}
+
+	return q, nil
+}
+
+// CompileNTT derives the QAP of a R1CS over the smallest domain with a point
+// for every constraint, enforcing constraint j at w^j and padding the points
+// left over with 0 * 0 = 0. The per-variable polynomials are dense in the
+// size of the domain and are never formed: Evaluate sums the matrix entries
+// against the Lagrange basis at the point, and Combine and Quotient
+// interpolate the rows evaluated against the witness with the NTT. This
+// scales to circuits far larger than Compile, whose basis is quadratic in
+// the number of constraints.
+func CompileNTT(r1cs *R1CS) (*QAP, error) {
+
+	var err error
+
+	var d *Domain
+	if d, err = SmallestDomain(r1cs.Field, len(r1cs.Constraints)); err != nil {
+		return nil, err
+	}
+
+	return &QAP{
+		Field:  r1cs.Field,
+		Roots:  d.Elements(),
+		Public: r1cs.Public(),
+		T:      d.Vanishing(),
+		Domain: d,
+		R1CS:   r1cs,
+	}, nil
+}
+
+// Evaluate computes every v_i(x), w_i(x), y_i(x) and t(x) at a point.
+func (q *QAP) Evaluate(x *field.Element) ([]*field.Element, []*field.Element, []*field.Element, *field.Element) {
+
+	if q.Domain != nil {
+		return q.evaluateDomain(x)
+	}
+
+	var v = make([]*field.Element, len(q.V))
+	var w = make([]*field.Element, len(q.W))
+	var y = make([]*field.Element, len(q.Y))
+
+	var i int
+	for i = range q.V {
+		v[i] = q.V[i].Evaluate(x)
+		w[i] = q.W[i].Evaluate(x)
+		y[i] = q.Y[i].Evaluate(x)
+	}
+
+	return v, w, y, q.T.Evaluate(x)
+}
+
+// Combine computes v(x) = sum a_i * v_i(x), and likewise w(x) and y(x), for
+// the witness values a_i. The witness must pass Check.
+func (q *QAP) Combine(witness []*field.Element) (*Polynomial, *Polynomial, *Polynomial) {
+
+	if q.Domain != nil {
+		return q.combineDomain(witness)
+	}
+
+	var v, w, y = NewPolynomial(q.Field), NewPolynomial(q.Field), NewPolynomial(q.Field)
+
+	var i int
+	for i = range witness {
+		v = v.Add(q.V[i].Scale(witness[i]))
+		w = w.Add(q.W[i].Scale(witness[i]))
+		y = y.Add(q.Y[i].Scale(witness[i]))
+	}
+
+	return v, w, y
+}
+
+// Check returns ErrWitnessLength if the witness does not have one value for
+// every variable of the QAP, and ErrWitnessConstant if it does not start with
+// the constant 1.
+func (q *QAP) Check(witness []*field.Element) error {
+
+	var variables = len(q.V)
+	if q.R1CS != nil {
+		variables = len(q.R1CS.Variables)
+	}
+
+	if len(witness) != variables {
+		return ErrWitnessLength
+	}
+
+	if !witness[0].IsOne() {
+		return ErrWitnessConstant
+	}
+
+	return nil
+}
+
+// Quotient computes h(x) = (v(x) * w(x) - y(x)) / t(x) for the witness. If the
+// witness does not satisfy the constraints the division has a remainder and
+// ErrNotDivisible is returned, and if it does not pass Check that error is.
+func (q *QAP) Quotient(witness []*field.Element) (*Polynomial, error) {
+
+	var err error
+
+	if err = q.Check(witness); err != nil {
+		return nil, err
+	}
+
+	if q.Domain != nil {
+		return q.Domain.QuotientNTT(q.R1CS, q.pad(witness))
+	}
+
+	var v, w, y = q.Combine(witness)
+
+	var h, remainder *Polynomial
+	if h, remainder, err = v.Mul(w).Sub(y).DivMod(q.T); err != nil {
+		return nil, err
+	}
+
+	if !remainder.IsZero() {
+		return nil, ErrNotDivisible
+	}
+
+	return h, nil
+}
+
+// evaluateDomain is Evaluate for a QAP from CompileNTT. Column i of A is
+// interpolated by v_i(x) = sum_j A[j][i] * l_j(x), so every matrix entry adds
+// a multiple of one Lagrange basis value.
+func (q *QAP) evaluateDomain(x *field.Element) ([]*field.Element, []*field.Element, []*field.Element, *field.Element) {
+
+	var basis = q.Domain.Lagrange(x)
+
+	var v = zeroElements(q.Field, len(q.R1CS.Variables))
+	var w = zeroElements(q.Field, len(q.R1CS.Variables))
+	var y = zeroElements(q.Field, len(q.R1CS.Variables))
+
+	var j int
+	var constraint Constraint
+
+	for j, constraint = range q.R1CS.Constraints {
+		accumulateAt(v, constraint.A, basis[j])
+		accumulateAt(w, constraint.B, basis[j])
+		accumulateAt(y, constraint.C, basis[j])
+	}
+
+	return v, w, y, q.T.Evaluate(x)
+}
+
+// combineDomain is Combine for a QAP from CompileNTT, which interpolates the
+// values of the rows of A, B and C at the witness.
+func (q *QAP) combineDomain(witness []*field.Element) (*Polynomial, *Polynomial, *Polynomial) {
+
+	witness = q.pad(witness)
+
+	var a = make([]*field.Element, len(q.R1CS.Constraints))
+	var b = make([]*field.Element, len(q.R1CS.Constraints))
+	var c = make([]*field.Element, len(q.R1CS.Constraints))
+
+	var j int
+	var constraint Constraint
+
+	for j, constraint = range q.R1CS.Constraints {
+		a[j] = constraint.A.Evaluate(q.Field, witness)
+		b[j] = constraint.B.Evaluate(q.Field, witness)
+		c[j] = constraint.C.Evaluate(q.Field, witness)
+	}
+
+	// The rows fit the domain, which CompileNTT chose for them.
+	var v, _ = q.Domain.InterpolateNTT(a)
+	var w, _ = q.Domain.InterpolateNTT(b)
+	var y, _ = q.Domain.InterpolateNTT(c)
+
+	return v, w, y
+}
+
+// pad extends a short witness with zeros, the values Combine assumes for the
+// variables past its end.
+func (q *QAP) pad(witness []*field.Element) []*field.Element {
+
+	var padded = make([]*field.Element, max(len(witness), len(q.R1CS.Variables)))
+
+	var i int
+	for i = range padded {
+
+		if i < len(witness) {
+			padded[i] = witness[i]
+			continue
+		}
+
+		padded[i] = q.Field.Zero()
+	}
+
+	return padded
+}
+
+// rootDetection encodes the QAP evaluated at a secret point together with the
+// witness, and validates e(V, W) - e(g, Y) = e(T, H) the same way as the hand
+// written examples.
+func rootDetection(q *QAP, witness []*field.Element) bool {
+
+	var err error
+
+	var g1 *bn256.G1
+	if _, g1, err = bn256.RandomG1(rand.Reader); err != nil {
+		fmt.Printf("parameter generation %v", err)
+	}
+
+	var g2 *bn256.G2
+	if _, g2, err = bn256.RandomG2(rand.Reader); err != nil {
+		fmt.Printf("parameter generation %v", err)
+	}
+
+	var s *field.Element
+	if s, err = q.Field.Rand(rand.Reader); err != nil {
+		fmt.Printf("parameter generation %v", err)
+	}
+
+	// The prover divides by t(x) symbolically, so a witness that does not
+	// satisfy the constraints is detected from the remainder.
+
+	var p *proof
+	if p, err = prove(q, witness, g1, g2, s); err != nil {
+		fmt.Printf("quotient polynomial %v \n", err)
+		return false
+	}
+
+	return p.verify(q, g1, g2, s)
+}
+
+// proof is the encoding of v(s), w(s), y(s) and h(s) checked by quadratic root
+// detection.
+type proof struct {
+	V *bn256.G1
+	W *bn256.G2
+	Y *bn256.G2
+	H *bn256.G2
+}
+
+// prove encodes the QAP combined with the witness at s. To make the SNARK
+// zero-knowledge, random multiples delta_v * t(x), delta_w * t(x) and
+// delta_y * t(x) are added to v(x), w(x) and y(x), so the encodings of two
+// proofs of the same statement are unrelated. The quotient becomes
+// h + dw * v + dv * w + dv * dw * t - dy to keep v * w - y = t * h.
+func prove(q *QAP, witness []*field.Element, g1 *bn256.G1, g2 *bn256.G2, s *field.Element) (*proof, error) {
+
+	var err error
+
+	var h *Polynomial
+	if h, err = q.Quotient(witness); err != nil {
+		return nil, err
+	}
+
+	var deltas = make([]*field.Element, 3)
+
+	var i int
+	for i = range deltas {
+		if deltas[i], err = q.Field.Rand(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+
+	var deltaV, deltaW, deltaY = deltas[0], deltas[1], deltas[2]
+
+	var v, w, y = q.Combine(witness)
+
+	h = h.Add(v.Scale(deltaW)).
+		Add(w.Scale(deltaV)).
+		Add(q.T.Scale(new(field.Element).Mul(deltaV, deltaW))).
+		Sub(NewPolynomial(q.Field, deltaY))
+
+	v = v.Add(q.T.Scale(deltaV))
+	w = w.Add(q.T.Scale(deltaW))
+	y = y.Add(q.T.Scale(deltaY))
+
+	return &proof{
+		V: new(bn256.G1).ScalarMult(g1, v.Evaluate(s).Big()), // E(v(s) + dv * t(s))
+		W: new(bn256.G2).ScalarMult(g2, w.Evaluate(s).Big()), // E(w(s) + dw * t(s))
+		Y: new(bn256.G2).ScalarMult(g2, y.Evaluate(s).Big()), // E(y(s) + dy * t(s))
+		H: new(bn256.G2).ScalarMult(g2, h.Evaluate(s).Big()),
+	}, nil
+}
+
+// verify uses quadratic root detection to validate the SNARK was constructed
+// with values that satisfy the arithmetic circuit.
+func (p *proof) verify(q *QAP, g1 *bn256.G1, g2 *bn256.G2, s *field.Element) bool {
+
+	var eT = new(bn256.G1).ScalarMult(g1, q.T.Evaluate(s).Big())
+
+	var left = new(bn256.GT).Add(
+		bn256.Pair(p.V, p.W),
+		new(bn256.GT).Neg(bn256.Pair(g1, p.Y)),
+	)
+
+	var right = bn256.Pair(eT, p.H)
+
+	return bytes.Equal(left.Marshal(), right.Marshal())
+}
+
+// randomRoots samples the points at which the constraints are enforced.
+func randomRoots(f *field.Field, n int) []*field.Element {
+
+	var err error
+
+	var roots = make([]*field.Element, n)
+
+	var i int
+	for i = range roots {
+		if roots[i], err = f.Rand(rand.Reader); err != nil {
+			fmt.Printf("parameter generation %v", err)
+		}
+	}
+
+	return roots
+}
+
+func zeroPolynomials(f *field.Field, count int) []*Polynomial {
+
+	var polynomials = make([]*Polynomial, count)
+
+	var i int
+	for i = range polynomials {
+		polynomials[i] = NewPolynomial(f)
+	}
+
+	return polynomials
+}
+
+// accumulate adds coeff * basis to the polynomial of every column referenced
+// by the row.
+func accumulate(polynomials []*Polynomial, row LinearCombination, basis *Polynomial) {
+
+	var term Term
+	for _, term = range row {
+		polynomials[term.Index] = polynomials[term.Index].Add(basis.Scale(term.Coeff))
+	}
+}
+
+// zeroElements returns count zeros of the field.
+func zeroElements(f *field.Field, count int) []*field.Element {
+
+	var elements = make([]*field.Element, count)
+
+	var i int
+	for i = range elements {
+		elements[i] = f.Zero()
+	}
+
+	return elements
+}
+
+// accumulateAt adds coeff * basis to the value of every column referenced by
+// the row.
+func accumulateAt(values []*field.Element, row LinearCombination, basis *field.Element) {
+
+	var term Term
+	for _, term = range row {
+		values[term.Index].Add(values[term.Index], new(field.Element).Mul(term.Coeff, basis))
+	}
+}
@@ -0,0 +1,140 @@
+package qap
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestCompile(t *testing.T) {
+
+	var err error
+
+	var f = field.New(big.NewInt(997))
+	var r1, r2 = f.NewInt64(3), f.NewInt64(7)
+
+	var r1cs, _ = e2R1CS(f)
+
+	var q *QAP
+	if q, err = Compile(r1cs, []*field.Element{r1, r2}); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	// v0(r1) = 0, v0(r2) = 1 | w2(r1) = 1, w2(r2) = -7 | y5(r1) = 0, y5(r2) = 1
+
+	var cases = []struct {
+		name     string
+		p        *Polynomial
+		at       *field.Element
+		expected int64
+	}{
+		{"v0(r1)", q.V[0], r1, 0},
+		{"v0(r2)", q.V[0], r2, 1},
+		{"v1(r1)", q.V[1], r1, 4},
+		{"w2(r1)", q.W[2], r1, 1},
+		{"w2(r2)", q.W[2], r2, -7},
+		{"w4(r2)", q.W[4], r2, 3},
+		{"y3(r1)", q.Y[3], r1, 1},
+		{"y5(r1)", q.Y[5], r1, 0},
+		{"y5(r2)", q.Y[5], r2, 1},
+		{"t(r1)", q.T, r1, 0},
+		{"t(r2)", q.T, r2, 0},
+	}
+
+	var c = cases[0]
+	for _, c = range cases {
+
+		var actual = c.p.Evaluate(c.at)
+
+		if !actual.Equal(f.NewInt64(c.expected)) {
+			t.Errorf("%s = %d, expected %d", c.name, actual, c.expected)
+		}
+	}
+
+	if _, err = Compile(r1cs, []*field.Element{r1}); err != ErrRootCount {
+		t.Errorf("expected %v, got %v", ErrRootCount, err)
+	}
+
+	if _, err = Compile(r1cs, []*field.Element{r1, f.NewInt64(1000)}); err != ErrDuplicateRoot {
+		t.Errorf("expected %v, got %v", ErrDuplicateRoot, err)
+	}
+}
+
+func TestCompileRootDetection(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+
+	var q *QAP
+	if q, err = Compile(r1cs, randomRoots(f, len(r1cs.Constraints))); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	if !rootDetection(q, s) {
+		t.Errorf("expected the witness to pass quadratic root detection")
+	}
+
+	// 3 * 3 * 3 + 3 + 5 = 35 != 36
+	s[4] = f.NewInt64(36)
+
+	if _, err = q.Quotient(s); err != ErrNotDivisible {
+		t.Errorf("expected %v, got %v", ErrNotDivisible, err)
+	}
+
+	if rootDetection(q, s) {
+		t.Errorf("expected a wrong witness to fail quadratic root detection")
+	}
+}
+
+func TestZeroKnowledge(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+
+	var q *QAP
+	if q, err = Compile(r1cs, randomRoots(f, len(r1cs.Constraints))); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	var g1 = new(bn256.G1).ScalarBaseMult(big.NewInt(1))
+	var g2 = new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+
+	var x = randomRoots(f, 1)[0]
+
+	var first, second *proof
+
+	if first, err = prove(q, s, g1, g2, x); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if second, err = prove(q, s, g1, g2, x); err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+
+	if !first.verify(q, g1, g2, x) || !second.verify(q, g1, g2, x) {
+		t.Errorf("expected both proofs to pass quadratic root detection")
+	}
+
+	// Without the random multiples of t(x) both proofs would encode the same
+	// v(s), w(s), y(s) and h(s) for the same statement.
+
+	if bytes.Equal(first.V.Marshal(), second.V.Marshal()) ||
+		bytes.Equal(first.W.Marshal(), second.W.Marshal()) ||
+		bytes.Equal(first.Y.Marshal(), second.Y.Marshal()) ||
+		bytes.Equal(first.H.Marshal(), second.H.Marshal()) {
+		t.Errorf("expected the encodings of the two proofs to be distinct")
+	}
+
+	if !E1SQAP(bn256.Order) {
+		t.Errorf("expected the blinded strong QAP to pass quadratic root detection")
+	}
+}
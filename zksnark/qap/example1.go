@@ -1,12 +1,10 @@
 package qap
 
 import (
-	"bytes"
-	"crypto/rand"
 	"fmt"
 	"math/big"
 
-	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
 )
 
 // f(x1) = 3 * x1
@@ -58,117 +56,20 @@ func E1QAP(order *big.Int) bool {
 
 	var err error
 
-	var g1 *bn256.G1
-	if _, g1, err = bn256.RandomG1(rand.Reader); err != nil {
-		fmt.Printf("error generating group element: %v \n", err)
-	}
-
-	var g2 *bn256.G2
-	if _, g2, err = bn256.RandomG2(rand.Reader); err != nil {
-		fmt.Printf("error generating group element %v \n", err)
-	}
-
-	var v [3]*bn256.G1
-	var leftG []*big.Int
-
-	leftG = append(
-		leftG,
-		big.NewInt(3), // v0(s)
-	)
-
-	leftG[0] = new(big.Int).Mul(big.NewInt(1), leftG[0])
-	v[0] = new(bn256.G1).ScalarMult(g1, leftG[0]) // E(v0(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0), // v1(s)
-	)
-
-	leftG[1] = new(big.Int).Mul(big.NewInt(2), leftG[1]) // a1 = 2
-	v[1] = new(bn256.G1).ScalarMult(g1, leftG[1])        // E(a1 * v1(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0),
-	)
-
-	leftG[2] = new(big.Int).Mul(big.NewInt(6), leftG[2]) // a2 = 6
-	v[2] = new(bn256.G1).ScalarMult(g1, leftG[2])        // E(a2 * v2(s))
-
-	var w [3]*bn256.G2
-	var rightG []*big.Int
-
-	rightG = append(
-		rightG,
-		big.NewInt(0), // w0(s)
-	)
-
-	rightG[0] = new(big.Int).Mul(big.NewInt(1), rightG[0])
-	w[0] = new(bn256.G2).ScalarMult(g2, rightG[0]) // E(w0(s))
-
-	rightG = append(
-		rightG,
-		big.NewInt(1), // w1(s)
-	)
-
-	rightG[1] = new(big.Int).Mul(big.NewInt(2), rightG[1]) // a1 = 2
-	w[1] = new(bn256.G2).ScalarMult(g2, rightG[1])         // E(a1 * w1(s))
-
-	rightG = append(
-		rightG,
-		big.NewInt(0), // w2(s)
-	)
-
-	rightG[2] = new(big.Int).Mul(big.NewInt(6), rightG[2]) // a2 = 6
-	w[2] = new(bn256.G2).ScalarMult(g2, rightG[2])         // E(a2 * v2(s))
-
-	var y [3]*bn256.G2
-	var outputG []*big.Int
-
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y0(s)
-	)
-
-	outputG[0] = new(big.Int).Mul(big.NewInt(1), outputG[0])
-	y[0] = new(bn256.G2).ScalarMult(g2, outputG[0]) // E(y0(s))
-
-	outputG = append(
-		outputG,
-		big.NewInt(0),
-	)
-
-	outputG[1] = new(big.Int).Mul(big.NewInt(2), outputG[1])
-	y[1] = new(bn256.G2).ScalarMult(g2, outputG[1])
-
-	outputG = append(
-		outputG,
-		big.NewInt(1),
-	)
+	// The interpolation polynomials derived above are computed from the
+	// constraints of E1R1CS, with the roots sampled at random.
 
-	outputG[2] = new(big.Int).Mul(big.NewInt(6), outputG[2])
-	y[2] = new(bn256.G2).ScalarMult(g2, outputG[2])
+	var f = field.New(order)
 
-	// Quadratic root detection to validate the SNARK was constructed with
-	// values that satisfy the arithmetic circuit.
+	var r1cs, s = e1R1CS(f)
 
-	var eV = new(bn256.G1).Add(v[0], new(bn256.G1).Add(v[1], v[2]))
-	var eW = new(bn256.G2).Add(w[0], new(bn256.G2).Add(w[1], w[2]))
-	var eY = bn256.Pair(g1, new(bn256.G2).Add(y[0], new(bn256.G2).Add(y[1], y[2])))
-
-	// return bytes.Equal(bn256.Pair(eV, eW).Marshal(), eY.Marshal())
-
-	var left = new(bn256.GT).Add(
-		bn256.Pair(eV, eW),
-		new(bn256.GT).Neg(eY),
-	)
-
-	var right = bn256.Pair(
-		g1,
-		new(bn256.G2).ScalarMult(g2, big.NewInt(0)),
-	)
+	var q *QAP
+	if q, err = Compile(r1cs, randomRoots(f, len(r1cs.Constraints))); err != nil {
+		fmt.Printf("qap compilation %v \n", err)
+		return false
+	}
 
-	return bytes.Equal(left.Marshal(), right.Marshal())
+	return rootDetection(q, s)
 }
 
 // vp_{0}(r1) = 0, vp_{0}(r2) = 0, vp_{0}(s1) = 1, vp_{0}(s2) = 1,
@@ -253,268 +154,58 @@ func E1QAP(order *big.Int) bool {
 //           + 1 * -----------------------------------------------
 //                  (r  - r1) * (r  - r2) * (r  - s1) * (r  - s2)
 
-// E1SQAP defines a string QAP for the arithmetic expression, uses it to create
+// E1SQAP defines a strong QAP for the arithmetic expression, uses it to create
 // a SNARK, and evaluates it.
 func E1SQAP(order *big.Int) bool {
 
 	var err error
 
-	var g1 *bn256.G1
-	if _, g1, err = bn256.RandomG1(rand.Reader); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
-
-	var g2 *bn256.G2
-	if _, g2, err = bn256.RandomG2(rand.Reader); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	// The polynomials derived above are computed from the strong constraints
+	// of E1R1CS, with the roots r, r1, r2, s1 and s2 sampled at random.
 
-	var r *big.Int // big.NewInt(10)
-	if r, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
-
-	var r1 *big.Int // big.NewInt(2)
-	if r1, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	var f = field.New(order)
 
-	var r2 *big.Int // big.NewInt(3)
-	if r2, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	var r1cs, s = e1R1CS(f)
+	var strong = r1cs.Strengthen()
 
-	var s *big.Int // big.NewInt(22)
-	if s, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
+	var q *QAP
+	if q, err = Compile(strong, randomRoots(f, len(strong.Constraints))); err != nil {
+		fmt.Printf("qap compilation %v \n", err)
+		return false
 	}
 
-	var s1 *big.Int // big.NewInt(5)
-	if s1, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	// A witness with 3 * a1 != a2 leaves a remainder when dividing by t(x),
+	// so no quotient polynomial exists for it.
+	var bad = []*field.Element{f.NewInt64(1), f.NewInt64(2), f.NewInt64(7)}
 
-	var s2 *big.Int // big.NewInt(7)
-	if s2, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
+	if _, err = q.Quotient(bad); err != ErrNotDivisible {
+		fmt.Printf("quotient of a bad witness %v \n", err)
+		return false
 	}
 
-	// var betaV *big.Int
-	// if betaV, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	// var betaW *big.Int
-	// if betaW, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	// var betaY *big.Int
-	// if betaY, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	var v [3]*bn256.G1
-	var leftG []*big.Int
-
-	leftG = append(
-		leftG,
-		Interpolate(
-			s, []int64{3, 1, 1},
-			BasisPolynomial(order, 0, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 3, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 4, []*big.Int{r, r1, r2, s1, s2}...),
-		), // v0(s)
-	)
-
-	// leftG[0] = new(big.Int).Mul(big.NewInt(1), leftG[0])
-	leftG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), leftG[0]), order)
-
-	v[0] = new(bn256.G1).ScalarMult(g1, leftG[0]) // E(v0(s))
-
-	leftG = append(
-		leftG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 1, []*big.Int{r, r1, r2, s1, s2}...),
-		), // v1(s)
-	)
-
-	// leftG[1] = new(big.Int).Mul(big.NewInt(2), leftG[1])                          // a1 = 2
-	leftG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), leftG[1]), order) // a1 = 2
-
-	v[1] = new(bn256.G1).ScalarMult(g1, leftG[1]) // E(a1 * v1(s))
-
-	leftG = append(
-		leftG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 2, []*big.Int{r, r1, r2, s1, s2}...),
-		), // v2(s)
-	)
-
-	// leftG[2] = new(big.Int).Mul(big.NewInt(6), leftG[2])                          // a2 = 6
-	leftG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(6), leftG[2]), order) // a2 = 6
-
-	v[2] = new(bn256.G1).ScalarMult(g1, leftG[2]) // E(a2 * v2(s))
-
-	var w [3]*bn256.G2
-	var rightG []*big.Int
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{1, 1},
-			BasisPolynomial(order, 1, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 2, []*big.Int{r, r1, r2, s1, s2}...),
-		), // w0(s)
-	)
-
-	// rightG[0] = new(big.Int).Mul(big.NewInt(1), rightG[0])
-	rightG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), rightG[0]), order)
-
-	w[0] = new(bn256.G2).ScalarMult(g2, rightG[0]) // E(w0(s))
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{1, 1},
-			BasisPolynomial(order, 0, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 3, []*big.Int{r, r1, r2, s1, s2}...),
-		), // w1(s)
-	)
-
-	// rightG[1] = new(big.Int).Mul(big.NewInt(2), rightG[1])                          // a1 = 2
-	rightG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), rightG[1]), order) // a1 = 2
-
-	w[1] = new(bn256.G2).ScalarMult(g2, rightG[1]) // E(a1 * w1(s))
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 4, []*big.Int{r, r1, r2, s1, s2}...),
-		), // w2(s)
-	)
-
-	// rightG[2] = new(big.Int).Mul(big.NewInt(6), rightG[2])                          // a2 = 6
-	rightG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(6), rightG[2]), order) // a2 = 6
-
-	w[2] = new(bn256.G2).ScalarMult(g2, rightG[2]) // E(a2 * w2(s))
-
-	var y [3]*bn256.G2
-	var outputG []*big.Int
-
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y0(s)
-	)
-
-	// outputG[0] = new(big.Int).Mul(big.NewInt(1), outputG[0])
-	outputG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), outputG[0]), order)
-
-	y[0] = new(bn256.G2).ScalarMult(g2, outputG[0]) // E(y0(s))
-
-	outputG = append(
-		outputG,
-		Interpolate(
-			s, []int64{1, 1},
-			BasisPolynomial(order, 1, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 3, []*big.Int{r, r1, r2, s1, s2}...),
-		), // y1(s)
-	)
-
-	// outputG[1] = new(big.Int).Mul(big.NewInt(2), outputG[1])                          // a1 = 2
-	outputG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), outputG[1]), order) // a1 = 2
-
-	y[1] = new(bn256.G2).ScalarMult(g2, outputG[1]) // E(a1 * y1(s))
-
-	outputG = append(
-		outputG,
-		Interpolate(
-			s, []int64{1, 1, 1},
-			BasisPolynomial(order, 0, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 2, []*big.Int{r, r1, r2, s1, s2}...),
-			BasisPolynomial(order, 4, []*big.Int{r, r1, r2, s1, s2}...),
-		), // y2(s)
-	)
-
-	// outputG[2] = new(big.Int).Mul(big.NewInt(6), outputG[2])                          // a2 = 6
-	outputG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(6), outputG[2]), order) // a2 = 6
-
-	y[2] = new(bn256.G2).ScalarMult(g2, outputG[2]) // E(a2 * y2(s))
-
-	var term1 = new(big.Int).Add(
-		leftG[0],
-		new(big.Int).Add(
-			leftG[1],
-			leftG[2],
-		),
-	)
-
-	var term2 = new(big.Int).Add(
-		rightG[0],
-		new(big.Int).Add(
-			rightG[1],
-			rightG[2],
-		),
-	)
-
-	var term3 = new(big.Int).Add(
-		outputG[0],
-		new(big.Int).Add(
-			outputG[1],
-			outputG[2],
-		),
-	)
-
-	var t = new(big.Int).Mod(
-		new(big.Int).Mul(
-			new(big.Int).Sub(s, r),
-			new(big.Int).Mul(
-				new(big.Int).Mul(
-					new(big.Int).Sub(s, r1),
-					new(big.Int).Sub(s, r2),
-				),
-				new(big.Int).Mul(
-					new(big.Int).Sub(s, s1),
-					new(big.Int).Sub(s, s2),
-				),
-			),
-		),
-		order,
-	)
-
-	var h = new(big.Int).Mod(
-		new(big.Int).Mul(
-			new(big.Int).Sub(
-				new(big.Int).Mul(term1, term2), term3,
-			),
-			new(big.Int).ModInverse(t, order),
-		),
-		order,
-	)
+	return rootDetection(q, s)
+}
 
-	// Quadratic root detection to validate the SNARK was constructed with
-	// values that satisfy the arithmetic circuit.
+// e1R1CS builds the constraint system and witness derived in E1R1CS.
+func e1R1CS(f *field.Field) (*R1CS, []*field.Element) {
 
-	var eV = new(bn256.G1).Add(v[0], new(bn256.G1).Add(v[1], v[2]))
-	var eW = new(bn256.G2).Add(w[0], new(bn256.G2).Add(w[1], w[2]))
-	var eY = bn256.Pair(g1, new(bn256.G2).Add(y[0], new(bn256.G2).Add(y[1], y[2])))
+	var r1cs = NewR1CS(f)
 
-	var eT = new(bn256.G1).ScalarMult(g1, t)
-	var eH = new(bn256.G2).ScalarMult(g2, h)
+	var a1 = r1cs.AddVariable("a1", false)
+	var a2 = r1cs.AddVariable("a2", true)
 
-	var left = new(bn256.GT).Add(
-		bn256.Pair(eV, eW),
-		new(bn256.GT).Neg(eY),
+	// 3 * a1 = a2
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(0, 3)},
+		LinearCombination{r1cs.NewTerm(a1, 1)},
+		LinearCombination{r1cs.NewTerm(a2, 1)},
 	)
 
-	var right = bn256.Pair(eT, eH)
-
-	// TODO: Include additional randomness to make the SNARK zero-knowledge
+	var s = []*field.Element{
+		f.NewInt64(1), f.NewInt64(2), f.NewInt64(6),
+	}
 
-	return bytes.Equal(left.Marshal(), right.Marshal())
+	return r1cs, s
 }
 
 // E1R1CS defines a R1CS that simplifies deriving the constraints for creating
@@ -549,5 +240,13 @@ func E1R1CS(order *big.Int) bool {
 
 	// TODO: Use QAP to generate linear PCPs (Probablistically Checkable Proofs)
 
+	var err error
+
+	var r1cs, s = e1R1CS(field.New(order))
+	if err = r1cs.IsSatisfied(s); err != nil {
+		fmt.Printf("constraint check %v \n", err)
+		return false
+	}
+
 	return true
 }
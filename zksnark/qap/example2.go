@@ -1,12 +1,10 @@
 package qap
 
 import (
-	"bytes"
-	"crypto/rand"
 	"fmt"
 	"math/big"
 
-	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
 )
 
 // f(x1, x2, x3, x4) = 4 * x1 * x2 - 7 * x2 + 3 * x4
@@ -137,349 +135,110 @@ func E2QAP(order *big.Int) bool {
 
 	var err error
 
-	var g1 *bn256.G1
-	if _, g1, err = bn256.RandomG1(rand.Reader); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	// The interpolation polynomials derived above are computed from the
+	// constraints of E2R1CS, with the roots sampled at random.
 
-	var g2 *bn256.G2
-	if _, g2, err = bn256.RandomG2(rand.Reader); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	var f = field.New(order)
 
-	var r1 *big.Int // big.NewInt(3)
-	if r1, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
+	var r1cs, s = e2R1CS(f)
 
-	var r2 *big.Int // big.NewInt(7)
-	if r2, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
+	var q *QAP
+	if q, err = Compile(r1cs, randomRoots(f, len(r1cs.Constraints))); err != nil {
+		fmt.Printf("qap compilation %v \n", err)
+		return false
 	}
 
-	var s *big.Int // big.NewInt(5)
-	if s, err = rand.Int(rand.Reader, order); err != nil {
-		fmt.Printf("parameter generation %v", err)
-	}
-
-	// var betaV *big.Int
-	// if betaV, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	// var betaW *big.Int
-	// if betaW, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	// var betaY *big.Int
-	// if betaY, err = rand.Int(rand.Reader, order); err != nil {
-	// 	fmt.Printf("parameter generation %v", err)
-	// }
-
-	var v [6]*bn256.G1
-	var leftG []*big.Int
-
-	leftG = append(
-		leftG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 1, []*big.Int{r1, r2}...),
-		), // v0(s)
-	)
-
-	// leftG[0] = new(big.Int).Mul(big.NewInt(1), leftG[0])
-	leftG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), leftG[0]), order)
-
-	v[0] = new(bn256.G1).ScalarMult(g1, leftG[0]) // E(v0(s))
-
-	leftG = append(
-		leftG,
-		Interpolate(
-			s, []int64{4},
-			BasisPolynomial(order, 0, []*big.Int{r1, r2}...),
-		), // v1(s)
-	)
-
-	// leftG[1] = new(big.Int).Mul(big.NewInt(3), leftG[1])                          // a1 = 3
-	leftG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(3), leftG[1]), order) // a1 = 3
-
-	v[1] = new(bn256.G1).ScalarMult(g1, leftG[1]) // E(a1 * v1(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0), // v2(s)
-	)
-
-	// leftG[2] = new(big.Int).Mul(big.NewInt(2), leftG[2])                          // a2 = 2
-	leftG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), leftG[2]), order) // a2 = 2
-
-	v[2] = new(bn256.G1).ScalarMult(g1, leftG[2]) // E(a2 * v2(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0), // v3(s)
-	)
-
-	// leftG[3] = new(big.Int).Mul(big.NewInt(24), leftG[3])                          // a3 = 24
-	leftG[3] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(24), leftG[3]), order) // a3 = 24
-
-	v[3] = new(bn256.G1).ScalarMult(g1, leftG[3]) // E(a3 * v3(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0), // v4(s)
-	)
-
-	// leftG[4] = new(big.Int).Mul(big.NewInt(1), leftG[4])                          // a4 = 1
-	leftG[4] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), leftG[4]), order) // a4 = 1
-
-	v[4] = new(bn256.G1).ScalarMult(g1, leftG[4]) // E(a4 * v4(s))
-
-	leftG = append(
-		leftG,
-		big.NewInt(0), // v5(s)
-	)
-
-	// leftG[5] = new(big.Int).Mul(big.NewInt(13), leftG[5])                          // a5 = 13
-	leftG[5] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(13), leftG[5]), order) // a5 = 13
-
-	v[5] = new(bn256.G1).ScalarMult(g1, leftG[5]) // E(a5 * v5(s))
-
-	var w [6]*bn256.G2
-	var rightG []*big.Int
-
-	rightG = append(
-		rightG,
-		big.NewInt(0), // w0(s)
-	)
-
-	// rightG[0] = new(big.Int).Mul(big.NewInt(1), rightG[0])
-	rightG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), rightG[0]), order)
-
-	w[0] = new(bn256.G2).ScalarMult(g2, rightG[0]) // E(w0(s))
-
-	rightG = append(
-		rightG,
-		big.NewInt(0), // w1(s)
-	)
-
-	// rightG[1] = new(big.Int).Mul(big.NewInt(3), rightG[1])                          // a1 = 3
-	rightG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(3), rightG[1]), order) // a1 = 3
-
-	w[1] = new(bn256.G2).ScalarMult(g2, rightG[1]) // E(a1 * w1(s))
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{1, -7},
-			BasisPolynomial(order, 0, []*big.Int{r1, r2}...),
-			BasisPolynomial(order, 1, []*big.Int{r1, r2}...),
-		), // w2(s)
-	)
-
-	// rightG[2] = new(big.Int).Mul(big.NewInt(2), rightG[2])                          // a2 = 2
-	rightG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), rightG[2]), order) // a2 = 2
-
-	w[2] = new(bn256.G2).ScalarMult(g2, rightG[2]) // E(a2 * w2(s))
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 1, []*big.Int{r1, r2}...),
-		), // w3(s)
-	)
-
-	// rightG[3] = new(big.Int).Mul(big.NewInt(24), rightG[3])                          // a3 = 24
-	rightG[3] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(24), rightG[3]), order) // a3 = 24
-
-	w[3] = new(bn256.G2).ScalarMult(g2, rightG[3]) // E(a3 * v3(s))
-
-	rightG = append(
-		rightG,
-		Interpolate(
-			s, []int64{3},
-			BasisPolynomial(order, 1, []*big.Int{r1, r2}...),
-		), // w4(s)
-	)
-
-	// rightG[4] = new(big.Int).Mul(big.NewInt(1), rightG[4])                          // a4 = 1
-	rightG[4] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), rightG[4]), order) // a4 = 1
-
-	w[4] = new(bn256.G2).ScalarMult(g2, rightG[4]) // E(a4 * w4(s))
-
-	rightG = append(
-		rightG,
-		big.NewInt(0), // w5(s)
-	)
-
-	// rightG[5] = new(big.Int).Mul(big.NewInt(13), rightG[5])                          // a5 = 13
-	rightG[5] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(13), rightG[5]), order) // a5 = 13
-
-	w[5] = new(bn256.G2).ScalarMult(g2, rightG[5]) // E(a5 * w5(s))
-
-	var y [6]*bn256.G2
-	var outputG []*big.Int
-
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y0(s)
-	)
-
-	// outputG[0] = new(big.Int).Mul(big.NewInt(1), outputG[0])
-	outputG[0] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), outputG[0]), order)
-
-	y[0] = new(bn256.G2).ScalarMult(g2, outputG[0]) // E(y0(s))
-
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y1(s)
-	)
-
-	// outputG[1] = new(big.Int).Mul(big.NewInt(3), outputG[1])                          // a1 = 3
-	outputG[1] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(3), outputG[1]), order) // a1 = 3
-
-	y[1] = new(bn256.G2).ScalarMult(g2, outputG[1]) // E(a1 * v1(s))
-
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y2(s)
-	)
-
-	// outputG[2] = new(big.Int).Mul(big.NewInt(2), outputG[2])                          // a2 = 2
-	outputG[2] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(2), outputG[2]), order) // a2 = 2
+	return rootDetection(q, s)
+}
 
-	y[2] = new(bn256.G2).ScalarMult(g2, outputG[2]) // E(a2 * y3(s))
+// E2SQAP defines a strong QAP for the arithmetic expression, uses it to create
+// a SNARK, and evaluates it.
+func E2SQAP(order *big.Int) bool {
 
-	outputG = append(
-		outputG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 0, []*big.Int{r1, r2}...),
-		), // y3(s)
-	)
+	var err error
 
-	// outputG[3] = new(big.Int).Mul(big.NewInt(24), outputG[3])                          // a3 = 24
-	outputG[3] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(24), outputG[3]), order) // a3 = 24
+	// As in E1SQAP, every variable a_k gets the two extra roots r_k and s_k
+	// at which a_k * 1 = a_k and 1 * a_k = a_k, after the roots of the
+	// constraints of E2R1CS.
 
-	y[3] = new(bn256.G2).ScalarMult(g2, outputG[3]) // E(a3 * y3(s))
+	var f = field.New(order)
 
-	outputG = append(
-		outputG,
-		big.NewInt(0), // y4(s)
-	)
+	var r1cs, s = e2R1CS(f)
+	var strong = r1cs.Strengthen()
 
-	// outputG[4] = new(big.Int).Mul(big.NewInt(3), outputG[4])                          // a4 = 1
-	outputG[4] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(1), outputG[4]), order) // a4 = 1
+	var q *QAP
+	if q, err = Compile(strong, randomRoots(f, len(strong.Constraints))); err != nil {
+		fmt.Printf("qap compilation %v \n", err)
+		return false
+	}
 
-	y[4] = new(bn256.G2).ScalarMult(g2, outputG[4]) // E(a4 * y4(s))
+	// A witness with 4 * a1 * a2 != a3 leaves a remainder when dividing by
+	// t(x), so no quotient polynomial exists for it.
+	var bad = []*field.Element{
+		f.NewInt64(1), f.NewInt64(3), f.NewInt64(2), f.NewInt64(25), f.NewInt64(1), f.NewInt64(13),
+	}
 
-	outputG = append(
-		outputG,
-		Interpolate(
-			s, []int64{1},
-			BasisPolynomial(order, 1, []*big.Int{r1, r2}...),
-		), // y5(s)
-	)
+	if _, err = q.Quotient(bad); err != ErrNotDivisible {
+		fmt.Printf("quotient of a bad witness %v \n", err)
+		return false
+	}
 
-	// outputG[5] = new(big.Int).Mul(big.NewInt(13), outputG[5])                          // a5 = 13
-	outputG[5] = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(13), outputG[5]), order) // a5 = 13
+	return rootDetection(q, s)
+}
 
-	y[5] = new(bn256.G2).ScalarMult(g2, outputG[5]) // E(a5 * y5(s))
+// E2OQAP defines a QAP for the arithmetic expression after optimizing its
+// constraints, and evaluates it. The second constraint of E2R1CS is linear and
+// the private a4 only appears there, so solving it for a4 removes both, and
+// the QAP has a single root. The output a5 is left unconstrained, as it was:
+// any a5 is reached by some choice of the private input a4.
+func E2OQAP(order *big.Int) bool {
 
-	var term1 = new(big.Int).Add(
-		new(big.Int).Add(leftG[0], leftG[1]),
-		new(big.Int).Add(
-			new(big.Int).Add(leftG[2], leftG[3]),
-			new(big.Int).Add(leftG[4], leftG[5]),
-		),
-	)
+	var err error
 
-	var term2 = new(big.Int).Add(
-		new(big.Int).Add(rightG[0], rightG[1]),
-		new(big.Int).Add(
-			new(big.Int).Add(rightG[2], rightG[3]),
-			new(big.Int).Add(rightG[4], rightG[5]),
-		),
-	)
+	var f = field.New(order)
 
-	var term3 = new(big.Int).Add(
-		new(big.Int).Add(outputG[0], outputG[1]),
-		new(big.Int).Add(
-			new(big.Int).Add(outputG[2], outputG[3]),
-			new(big.Int).Add(outputG[4], outputG[5]),
-		),
-	)
+	var r1cs, s = e2R1CS(f)
+	var o = r1cs.Optimize()
 
-	var t = new(big.Int).Mod(
-		new(big.Int).Mul(
-			new(big.Int).Sub(s, r1),
-			new(big.Int).Sub(s, r2),
-		),
-		order,
-	)
+	var q *QAP
+	if q, err = Compile(o.R1CS, randomRoots(f, o.ConstraintsAfter)); err != nil {
+		fmt.Printf("qap compilation %v \n", err)
+		return false
+	}
 
-	var h = new(big.Int).Mod(
-		new(big.Int).Mul(
-			new(big.Int).Sub(
-				new(big.Int).Mul(term1, term2), term3,
-			),
-			new(big.Int).ModInverse(t, order),
-		),
-		order,
-	)
+	return rootDetection(q, o.Witness(s))
+}
 
-	// Quadratic root detection to validate the SNARK was constructed with
-	// values that satisfy the arithmetic circuit.
+// e2R1CS builds the constraint system and witness derived in E2R1CS.
+func e2R1CS(f *field.Field) (*R1CS, []*field.Element) {
 
-	var eV = new(bn256.G1).Add(
-		new(bn256.G1).Add(v[0], v[1]),
-		new(bn256.G1).Add(
-			new(bn256.G1).Add(v[2], v[3]),
-			new(bn256.G1).Add(v[4], v[5]),
-		),
-	)
+	var r1cs = NewR1CS(f)
 
-	var eW = new(bn256.G2).Add(
-		new(bn256.G2).Add(w[0], w[1]),
-		new(bn256.G2).Add(
-			new(bn256.G2).Add(w[2], w[3]),
-			new(bn256.G2).Add(w[4], w[5]),
-		),
-	)
+	var a1 = r1cs.AddVariable("a1", false)
+	var a2 = r1cs.AddVariable("a2", false)
+	var a3 = r1cs.AddVariable("a3", false)
+	var a4 = r1cs.AddVariable("a4", false)
+	var a5 = r1cs.AddVariable("a5", true)
 
-	var eY = bn256.Pair(
-		g1,
-		new(bn256.G2).Add(
-			new(bn256.G2).Add(y[0], y[1]),
-			new(bn256.G2).Add(
-				new(bn256.G2).Add(y[2], y[3]),
-				new(bn256.G2).Add(y[4], y[5]),
-			),
-		),
+	// (4 * a1) * (a2) = a3
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(a1, 4)},
+		LinearCombination{r1cs.NewTerm(a2, 1)},
+		LinearCombination{r1cs.NewTerm(a3, 1)},
 	)
 
-	var eT = new(bn256.G1).ScalarMult(g1, t)
-	var eH = new(bn256.G2).ScalarMult(g2, h)
-
-	var left = new(bn256.GT).Add(
-		bn256.Pair(eV, eW),
-		new(bn256.GT).Neg(eY),
+	// 1 * ((-7) * a2 + 1 * a3 + 3 * a4) = a5
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(0, 1)},
+		LinearCombination{r1cs.NewTerm(a2, -7), r1cs.NewTerm(a3, 1), r1cs.NewTerm(a4, 3)},
+		LinearCombination{r1cs.NewTerm(a5, 1)},
 	)
 
-	var right = bn256.Pair(eT, eH)
-
-	// TODO: Include additional randomness to make the SNARK zero-knowledge
-
-	return bytes.Equal(left.Marshal(), right.Marshal())
-}
-
-// E2SQAP defines a strong QAP for the arithmetic expression, uses it to create
-// a SNARK, and evaluates it.
-func E2SQAP(order *big.Int) bool {
+	var s = []*field.Element{
+		f.NewInt64(1), f.NewInt64(3), f.NewInt64(2), f.NewInt64(24), f.NewInt64(1), f.NewInt64(13),
+	}
 
-	return true
+	return r1cs, s
 }
 
 // E2R1CS generates the quadratic arithmetic program to validate arithmetic
@@ -532,5 +291,13 @@ func E2R1CS(order *big.Int) bool {
 
 	// TODO: Use QAP to generate linear PCPs (Probablistically Checkable Proofs)
 
+	var err error
+
+	var r1cs, s = e2R1CS(field.New(order))
+	if err = r1cs.IsSatisfied(s); err != nil {
+		fmt.Printf("constraint check %v \n", err)
+		return false
+	}
+
 	return true
 }
@@ -0,0 +1,475 @@
+package qap
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// ErrDomainSize is returned when a domain size does not divide the order of
+// the field minus one, so the field has no subgroup of that size. For the
+// order of the cloudflare bn256 groups p - 1 = 2^5 * 3 * 5743 * 1868033^3 * c,
+// so the sizes up to 96 are products of 2 and 3 only, and larger domains need
+// the factor 5743.
+var ErrDomainSize = errors.New("domain size must divide the order minus one")
+
+// ErrDomainOverflow is returned when there are more values than points in the
+// domain.
+var ErrDomainOverflow = errors.New("number of values exceeds the size of the domain")
+
+// MaxDomainSize bounds the search of SmallestDomain.
+const MaxDomainSize = 1 << 26
+
+// Domain is the multiplicative subgroup {1, w, w^2, ..., w^(n - 1)} of the
+// field generated by a primitive n-th root of unity w, for any n dividing the
+// order of the field minus one. Evaluating and interpolating over it uses the
+// number theoretic transform (NTT), the finite field analogue of the FFT,
+// with mixed radix Cooley-Tukey steps for the prime factors of n.
+type Domain struct {
+	Field *field.Field
+	Size  int
+
+	// Generator is the primitive n-th root of unity w.
+	Generator *field.Element
+
+	// Shift is an element outside the subgroup, so the coset Shift * {w^i}
+	// does not contain any of the roots of t(x) = x^n - 1.
+	Shift *field.Element
+
+	generatorInv *field.Element
+	shiftInv     *field.Element
+	sizeInv      *field.Element
+
+	// radices are the prime factors of the size, with multiplicity.
+	radices []int
+}
+
+// NewDomain finds the subgroup of the given size. For the cofactor
+// c = (p - 1) / n the n-th roots of unity are the powers z^c, and z^c has
+// order exactly n unless (z^c)^(n / r) = 1 for a prime r dividing n, so the
+// smallest z that passes this test gives the generator.
+func NewDomain(f *field.Field, size int) (*Domain, error) {
+
+	if size < 1 {
+		return nil, ErrDomainSize
+	}
+
+	var n = big.NewInt(int64(size))
+
+	var cofactor, remainder = new(big.Int).DivMod(new(big.Int).Sub(f.Modulus(), big.NewInt(1)), n, new(big.Int))
+	if remainder.Sign() != 0 {
+		return nil, ErrDomainSize
+	}
+
+	var radices = factor(size)
+
+	var z = f.NewInt64(2)
+	var w = new(field.Element).Exp(z, cofactor)
+
+	for !primitive(w, size, radices) {
+		z.Add(z, f.One())
+		w.Exp(z, cofactor)
+	}
+
+	var shift = f.NewInt64(2)
+	for new(field.Element).Exp(shift, n).IsOne() {
+		shift.Add(shift, f.One())
+	}
+
+	return &Domain{
+		Field:        f,
+		Size:         size,
+		Generator:    w,
+		Shift:        shift,
+		generatorInv: new(field.Element).Inv(w),
+		shiftInv:     new(field.Element).Inv(shift),
+		sizeInv:      new(field.Element).Inv(f.NewInt64(int64(size))),
+		radices:      radices,
+	}, nil
+}
+
+// SmallestDomain returns the smallest domain with at least the given number
+// of points. Over bn256 a circuit of up to 96 constraints gets a domain of
+// the same order, but one more jumps to 5743 points.
+func SmallestDomain(f *field.Field, size int) (*Domain, error) {
+
+	var order = new(big.Int).Sub(f.Modulus(), big.NewInt(1))
+
+	var n int
+	for n = max(size, 1); n <= MaxDomainSize; n++ {
+		if new(big.Int).Mod(order, big.NewInt(int64(n))).Sign() == 0 {
+			return NewDomain(f, n)
+		}
+	}
+
+	return nil, ErrDomainSize
+}
+
+// Elements lists the points of the domain, 1, w, w^2, ... in order.
+func (d *Domain) Elements() []*field.Element {
+
+	var elements = make([]*field.Element, d.Size)
+
+	elements[0] = d.Field.One()
+
+	var i int
+	for i = 1; i < d.Size; i++ {
+		elements[i] = new(field.Element).Mul(elements[i-1], d.Generator)
+	}
+
+	return elements
+}
+
+// Vanishing returns t(x) = x^n - 1, which is zero on every point of the
+// domain.
+func (d *Domain) Vanishing() *Polynomial {
+
+	var coeffs = make([]*field.Element, d.Size+1)
+
+	var k int
+	for k = range coeffs {
+		coeffs[k] = d.Field.Zero()
+	}
+
+	coeffs[0] = d.Field.NewInt64(-1)
+	coeffs[d.Size] = d.Field.One()
+
+	return &Polynomial{field: d.Field, coeffs: coeffs}
+}
+
+// NTT evaluates the polynomial with the given coefficients at every point of
+// the domain.
+func (d *Domain) NTT(coeffs []*field.Element) ([]*field.Element, error) {
+	return d.transform(coeffs, d.Generator)
+}
+
+// INTT interpolates the values at the points of the domain and returns the
+// coefficients of the polynomial of degree less than n through them.
+func (d *Domain) INTT(values []*field.Element) ([]*field.Element, error) {
+
+	var err error
+
+	var coeffs []*field.Element
+	if coeffs, err = d.transform(values, d.generatorInv); err != nil {
+		return nil, err
+	}
+
+	var k int
+	for k = range coeffs {
+		coeffs[k].Mul(coeffs[k], d.sizeInv)
+	}
+
+	return coeffs, nil
+}
+
+// CosetNTT evaluates the polynomial at the points Shift * w^i. This is used
+// to divide by t(x), which is the non-zero constant Shift^n - 1 on the coset.
+func (d *Domain) CosetNTT(coeffs []*field.Element) ([]*field.Element, error) {
+
+	if len(coeffs) > d.Size {
+		return nil, ErrDomainOverflow
+	}
+
+	return d.NTT(d.distribute(coeffs, d.Shift))
+}
+
+// CosetINTT is the inverse of CosetNTT.
+func (d *Domain) CosetINTT(values []*field.Element) ([]*field.Element, error) {
+
+	var err error
+
+	var coeffs []*field.Element
+	if coeffs, err = d.INTT(values); err != nil {
+		return nil, err
+	}
+
+	return d.distribute(coeffs, d.shiftInv), nil
+}
+
+// InterpolateNTT returns the polynomial through the values at the points of
+// the domain.
+func (d *Domain) InterpolateNTT(values []*field.Element) (*Polynomial, error) {
+
+	var err error
+
+	var coeffs []*field.Element
+	if coeffs, err = d.INTT(values); err != nil {
+		return nil, err
+	}
+
+	return (&Polynomial{field: d.Field, coeffs: coeffs}).trim(), nil
+}
+
+// MulNTT multiplies two polynomials by evaluating them over a domain large
+// enough for the product, multiplying pointwise and interpolating.
+func MulNTT(p, q *Polynomial) (*Polynomial, error) {
+
+	var err error
+
+	if p.IsZero() || q.IsZero() {
+		return NewPolynomial(p.field), nil
+	}
+
+	var d *Domain
+	if d, err = SmallestDomain(p.field, len(p.coeffs)+len(q.coeffs)-1); err != nil {
+		return nil, err
+	}
+
+	var pValues, qValues []*field.Element
+
+	if pValues, err = d.NTT(p.coeffs); err != nil {
+		return nil, err
+	}
+
+	if qValues, err = d.NTT(q.coeffs); err != nil {
+		return nil, err
+	}
+
+	var i int
+	for i = range pValues {
+		pValues[i].Mul(pValues[i], qValues[i])
+	}
+
+	return d.InterpolateNTT(pValues)
+}
+
+// QuotientNTT computes h(x) = (v(x) * w(x) - y(x)) / t(x) for a R1CS whose
+// constraints are enforced at the points of the domain, with t(x) = x^n - 1.
+// Unlike QAP.Quotient it never forms the per-variable polynomials: the rows
+// of A, B and C are evaluated against the witness, interpolated once, and the
+// division is done pointwise on the coset where t(x) has no roots.
+func (d *Domain) QuotientNTT(r1cs *R1CS, witness []*field.Element) (*Polynomial, error) {
+
+	var err error
+
+	if len(r1cs.Constraints) > d.Size {
+		return nil, ErrDomainOverflow
+	}
+
+	var a = make([]*field.Element, len(r1cs.Constraints))
+	var b = make([]*field.Element, len(r1cs.Constraints))
+	var c = make([]*field.Element, len(r1cs.Constraints))
+
+	var j int
+	var constraint Constraint
+
+	for j, constraint = range r1cs.Constraints {
+		a[j] = constraint.A.Evaluate(d.Field, witness)
+		b[j] = constraint.B.Evaluate(d.Field, witness)
+		c[j] = constraint.C.Evaluate(d.Field, witness)
+
+		// t(x) divides v(x) * w(x) - y(x) exactly when it is zero on the
+		// domain, i.e. when every constraint holds.
+		if !new(field.Element).Mul(a[j], b[j]).Equal(c[j]) {
+			return nil, ErrNotDivisible
+		}
+	}
+
+	var values = [][]*field.Element{a, b, c}
+
+	var k int
+	for k = range values {
+
+		if values[k], err = d.INTT(values[k]); err != nil {
+			return nil, err
+		}
+
+		if values[k], err = d.CosetNTT(values[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	// t(Shift * w^i) = Shift^n * w^(i * n) - 1 = Shift^n - 1
+	var tInv = new(field.Element).Exp(d.Shift, big.NewInt(int64(d.Size)))
+	tInv.Inv(tInv.Sub(tInv, d.Field.One()))
+
+	var h = make([]*field.Element, d.Size)
+
+	for j = range h {
+		h[j] = new(field.Element).Mul(values[0][j], values[1][j])
+		h[j].Sub(h[j], values[2][j])
+		h[j].Mul(h[j], tInv)
+	}
+
+	if h, err = d.CosetINTT(h); err != nil {
+		return nil, err
+	}
+
+	return (&Polynomial{field: d.Field, coeffs: h}).trim(), nil
+}
+
+// distribute multiplies the coefficient of x^k by c^k, so that evaluating the
+// result at x is the same as evaluating the input at c * x.
+func (d *Domain) distribute(coeffs []*field.Element, c *field.Element) []*field.Element {
+
+	var distributed = make([]*field.Element, len(coeffs))
+	var power = d.Field.One()
+
+	var k int
+	for k = range coeffs {
+		distributed[k] = new(field.Element).Mul(coeffs[k], power)
+		power.Mul(power, c)
+	}
+
+	return distributed
+}
+
+// Lagrange evaluates the Lagrange basis of the domain at x,
+//
+//	l_j(x) = w^j * (x^n - 1) / (n * (x - w^j))
+//
+// which is one at w^j and zero at the other points, in O(n) operations.
+func (d *Domain) Lagrange(x *field.Element) []*field.Element {
+
+	var basis = make([]*field.Element, d.Size)
+
+	var t = new(field.Element).Exp(x, big.NewInt(int64(d.Size)))
+	t.Sub(t, d.Field.One())
+
+	var point = d.Field.One()
+	var scale = new(field.Element).Mul(t, d.sizeInv)
+
+	var j int
+	for j = range basis {
+
+		// x is a point of the domain, and the basis is an indicator.
+		if t.IsZero() {
+
+			basis[j] = d.Field.Zero()
+			if point.Equal(x) {
+				basis[j] = d.Field.One()
+			}
+
+			point.Mul(point, d.Generator)
+			continue
+		}
+
+		basis[j] = new(field.Element).Sub(x, point)
+		basis[j].Inv(basis[j])
+		basis[j].Mul(basis[j], point)
+		basis[j].Mul(basis[j], scale)
+
+		point.Mul(point, d.Generator)
+	}
+
+	return basis
+}
+
+// transform evaluates at the powers of root. The input is padded with zeros
+// to the size of the domain and is not modified.
+func (d *Domain) transform(input []*field.Element, root *field.Element) ([]*field.Element, error) {
+
+	if len(input) > d.Size {
+		return nil, ErrDomainOverflow
+	}
+
+	var values = make([]*field.Element, d.Size)
+
+	var i int
+	for i = range values {
+
+		if i < len(input) {
+			values[i] = new(field.Element).Set(input[i])
+			continue
+		}
+
+		values[i] = d.Field.Zero()
+	}
+
+	return d.dft(values, root, d.radices), nil
+}
+
+// dft is the recursive mixed radix Cooley-Tukey algorithm. For n = r * m the
+// r interleaved subsequences x_t, x_(t + r), ... of length m are transformed
+// with root^r into Y_t, and
+//
+//	X[k + m * q] = sum_t (root^(t * k) * Y_t[k]) * (root^m)^(t * q)
+//
+// is a transform of size r for every k < m.
+func (d *Domain) dft(values []*field.Element, root *field.Element, radices []int) []*field.Element {
+
+	var n = len(values)
+	if n == 1 {
+		return values
+	}
+
+	var r = radices[0]
+	var m = n / r
+
+	var subroot = new(field.Element).Exp(root, big.NewInt(int64(r)))
+
+	var sub = make([][]*field.Element, r)
+
+	var t, k int
+	for t = range sub {
+
+		var part = make([]*field.Element, m)
+		for k = range part {
+			part[k] = values[k*r+t]
+		}
+
+		sub[t] = d.dft(part, subroot, radices[1:])
+	}
+
+	var b = newButterfly(d.Field, r, new(field.Element).Exp(root, big.NewInt(int64(m))))
+
+	var transformed = make([]*field.Element, n)
+	var z = make([]*field.Element, r)
+
+	var twiddle = d.Field.One()
+
+	for k = 0; k < m; k++ {
+
+		var power = d.Field.One()
+
+		for t = range z {
+			z[t] = new(field.Element).Mul(sub[t][k], power)
+			power.Mul(power, twiddle)
+		}
+
+		var q int
+		var x *field.Element
+
+		for q, x = range b.transform(z) {
+			transformed[k+m*q] = x
+		}
+
+		twiddle.Mul(twiddle, root)
+	}
+
+	return transformed
+}
+
+// factor returns the prime factors of n with multiplicity, largest first.
+func factor(n int) []int {
+
+	var factors []int
+
+	var r int
+	for r = 2; r*r <= n; r++ {
+		for n%r == 0 {
+			factors = append([]int{r}, factors...)
+			n /= r
+		}
+	}
+
+	if n > 1 {
+		factors = append([]int{n}, factors...)
+	}
+
+	return factors
+}
+
+// primitive reports whether w, an n-th root of unity, has order exactly n.
+func primitive(w *field.Element, n int, radices []int) bool {
+
+	var r int
+	for _, r = range radices {
+		if new(field.Element).Exp(w, big.NewInt(int64(n/r))).IsOne() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,454 @@
+package qap
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func randomPolynomial(t *testing.T, f *field.Field, length int) *Polynomial {
+
+	var err error
+
+	var coeffs = make([]*field.Element, length)
+
+	var k int
+	for k = range coeffs {
+		if coeffs[k], err = f.Rand(rand.Reader); err != nil {
+			t.Fatalf("sampling failed: %v", err)
+		}
+	}
+
+	return NewPolynomial(f, coeffs...)
+}
+
+func TestNewDomain(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var d *Domain
+	if d, err = NewDomain(f, 96); err != nil {
+		t.Fatalf("expected a domain of size 96: %v", err)
+	}
+
+	// w^(n / 2) = -1 and w^(n / 3) != 1 for a primitive n-th root of unity.
+	var half = new(field.Element).Exp(d.Generator, big.NewInt(48))
+	if !half.Equal(f.NewInt64(-1)) {
+		t.Errorf("w^48 = %d, expected -1", half)
+	}
+
+	if new(field.Element).Exp(d.Generator, big.NewInt(32)).IsOne() {
+		t.Errorf("w^32 = 1, expected a primitive root")
+	}
+
+	// The order minus one is 2^5 * 3 * 5743 * ...
+	if _, err = NewDomain(f, 96*5743); err != nil {
+		t.Errorf("expected a domain of size 96 * 5743: %v", err)
+	}
+
+	var size int
+	for _, size = range []int{0, 7, 64} {
+		if _, err = NewDomain(f, size); err != ErrDomainSize {
+			t.Errorf("size %d: expected %v, got %v", size, ErrDomainSize, err)
+		}
+	}
+
+	var tests = []struct {
+		size     int
+		expected int
+	}{
+		{0, 1},
+		{5, 6},
+		{40, 48},
+		{96, 96},
+		{97, 5743},
+		{10000, 11486},
+	}
+
+	var test struct {
+		size     int
+		expected int
+	}
+
+	for _, test = range tests {
+
+		if d, err = SmallestDomain(f, test.size); err != nil {
+			t.Errorf("size %d: smallest domain failed: %v", test.size, err)
+			continue
+		}
+
+		if d.Size != test.expected {
+			t.Errorf("size %d: domain of %d points, expected %d", test.size, d.Size, test.expected)
+		}
+	}
+}
+
+func TestNTT(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	// Radix 2, mixed radix 2 and 3, and Bluestein's algorithm for 5743.
+	var size int
+	for _, size = range []int{16, 96, 5743} {
+
+		var d *Domain
+		if d, err = NewDomain(f, size); err != nil {
+			t.Fatalf("domain construction failed: %v", err)
+		}
+
+		var p = randomPolynomial(t, f, size*2/3)
+
+		// Evaluating directly is quadratic, so only some of the points of
+		// the large domain are checked.
+		var step = size/64 + 1
+
+		var values []*field.Element
+		if values, err = d.NTT(p.Coefficients()); err != nil {
+			t.Fatalf("transform failed: %v", err)
+		}
+
+		var i int
+		var point *field.Element
+
+		for i, point = range d.Elements() {
+			if i%step == 0 && !values[i].Equal(p.Evaluate(point)) {
+				t.Fatalf("size %d: NTT[%d] = %d, expected %d", size, i, values[i], p.Evaluate(point))
+			}
+		}
+
+		var interpolated *Polynomial
+		if interpolated, err = d.InterpolateNTT(values); err != nil || !interpolated.Equal(p) {
+			t.Errorf("size %d: expected INTT to invert NTT", size)
+		}
+
+		if values, err = d.CosetNTT(p.Coefficients()); err != nil {
+			t.Fatalf("transform failed: %v", err)
+		}
+
+		for i, point = range d.Elements() {
+
+			var shifted = new(field.Element).Mul(d.Shift, point)
+
+			if i%step == 0 && !values[i].Equal(p.Evaluate(shifted)) {
+				t.Fatalf("size %d: CosetNTT[%d] = %d, expected %d", size, i, values[i], p.Evaluate(shifted))
+			}
+		}
+
+		var coeffs []*field.Element
+		if coeffs, err = d.CosetINTT(values); err != nil || !NewPolynomial(f, coeffs...).Equal(p) {
+			t.Errorf("size %d: expected CosetINTT to invert CosetNTT", size)
+		}
+
+		if _, err = d.NTT(randomPolynomial(t, f, size+1).Coefficients()); err != ErrDomainOverflow {
+			t.Errorf("size %d: expected %v, got %v", size, ErrDomainOverflow, err)
+		}
+	}
+}
+
+func TestLagrange(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var d *Domain
+	if d, err = NewDomain(f, 12); err != nil {
+		t.Fatalf("domain construction failed: %v", err)
+	}
+
+	var values = make([]*field.Element, d.Size)
+
+	var i int
+	for i = range values {
+		values[i] = f.NewInt64(int64(i * i))
+	}
+
+	var p *Polynomial
+	if p, err = d.InterpolateNTT(values); err != nil {
+		t.Fatalf("interpolation failed: %v", err)
+	}
+
+	var x *field.Element
+	if x, err = f.Rand(rand.Reader); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	var point *field.Element
+	for _, point = range []*field.Element{x, d.Generator} {
+
+		var sum = f.Zero()
+
+		var l *field.Element
+		for i, l = range d.Lagrange(point) {
+			sum.Add(sum, new(field.Element).Mul(l, values[i]))
+		}
+
+		if !sum.Equal(p.Evaluate(point)) {
+			t.Errorf("sum l_j(%d) * y_j = %d, expected %d", point, sum, p.Evaluate(point))
+		}
+	}
+}
+
+func TestMulNTT(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var p, q = randomPolynomial(t, f, 37), randomPolynomial(t, f, 20)
+
+	var product *Polynomial
+	if product, err = MulNTT(p, q); err != nil {
+		t.Fatalf("multiplication failed: %v", err)
+	}
+
+	if !product.Equal(p.Mul(q)) {
+		t.Errorf("NTT product differs from schoolbook multiplication")
+	}
+}
+
+func TestQuotientNTT(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+
+	var d *Domain
+	if d, err = NewDomain(f, 4); err != nil {
+		t.Fatalf("domain construction failed: %v", err)
+	}
+
+	var expected, actual *Polynomial
+	if actual, err = d.QuotientNTT(r1cs, s); err != nil {
+		t.Fatalf("quotient failed: %v", err)
+	}
+
+	// The same QAP compiled at every point of the domain, where the padding
+	// constraint 0 * 0 = 0 holds for any witness.
+	r1cs.AddConstraint(nil, nil, nil)
+
+	var q *QAP
+	if q, err = Compile(r1cs, d.Elements()); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	if !q.T.Equal(d.Vanishing()) {
+		t.Errorf("t(x) = %s, expected x^4 - 1", q.T)
+	}
+
+	if expected, err = q.Quotient(s); err != nil {
+		t.Fatalf("quotient failed: %v", err)
+	}
+
+	if !actual.Equal(expected) {
+		t.Errorf("h(x) = %s, expected %s", actual, expected)
+	}
+
+	s[4] = f.NewInt64(36)
+
+	if _, err = d.QuotientNTT(r1cs, s); err != ErrNotDivisible {
+		t.Errorf("expected %v, got %v", ErrNotDivisible, err)
+	}
+}
+
+func TestCompileNTT(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+
+	var q *QAP
+	if q, err = CompileNTT(r1cs); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	// Three constraints fit the subgroup of order 3 exactly.
+	if q.Domain.Size != 3 || q.V != nil {
+		t.Fatalf("expected a domain of 3 points and no dense polynomials")
+	}
+
+	var dense *QAP
+	if dense, err = Compile(r1cs, q.Domain.Elements()); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	var x *field.Element
+	if x, err = f.Rand(rand.Reader); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	var v, w, y, tx = q.Evaluate(x)
+	var dv, dw, dy, dt = dense.Evaluate(x)
+
+	var i int
+	for i = range dv {
+		if !v[i].Equal(dv[i]) || !w[i].Equal(dw[i]) || !y[i].Equal(dy[i]) {
+			t.Errorf("variable %d evaluates differently from the dense QAP", i)
+		}
+	}
+
+	if !tx.Equal(dt) {
+		t.Errorf("t(x) = %d, expected %d", tx, dt)
+	}
+
+	var cv, cw, cy = q.Combine(s)
+	var ev, ew, ey = dense.Combine(s)
+
+	if !cv.Equal(ev) || !cw.Equal(ew) || !cy.Equal(ey) {
+		t.Errorf("combined polynomials differ from the dense QAP")
+	}
+
+	var h, expected *Polynomial
+	if h, err = q.Quotient(s); err != nil {
+		t.Fatalf("quotient failed: %v", err)
+	}
+
+	if expected, err = dense.Quotient(s); err != nil {
+		t.Fatalf("quotient failed: %v", err)
+	}
+
+	if !h.Equal(expected) {
+		t.Errorf("h(x) = %s, expected %s", h, expected)
+	}
+
+	s[4] = f.NewInt64(36)
+
+	if _, err = q.Quotient(s); err != ErrNotDivisible {
+		t.Errorf("expected %v, got %v", ErrNotDivisible, err)
+	}
+}
+
+// squaring returns the constraints x_(i + 1) = x_i * x_i for i < n, with the
+// last x_n public, and their witness for x_0 = 3.
+func squaring(f *field.Field, n int) (*R1CS, []*field.Element) {
+
+	var r1cs = NewR1CS(f)
+
+	var witness = []*field.Element{f.One(), f.NewInt64(3)}
+	var previous = r1cs.AddVariable("x0", false)
+
+	var i int
+	for i = 1; i <= n; i++ {
+
+		var next = r1cs.AddVariable("x"+strconv.Itoa(i), i == n)
+
+		r1cs.AddConstraint(
+			LinearCombination{r1cs.NewTerm(previous, 1)},
+			LinearCombination{r1cs.NewTerm(previous, 1)},
+			LinearCombination{r1cs.NewTerm(next, 1)},
+		)
+
+		witness = append(witness, new(field.Element).Mul(witness[previous], witness[previous]))
+		previous = next
+	}
+
+	return r1cs, witness
+}
+
+// checkQuotient checks v(x) * w(x) - y(x) = h(x) * t(x) at a random x from
+// the evaluations alone.
+func checkQuotient(t *testing.T, q *QAP, witness []*field.Element, h *Polynomial) {
+
+	var err error
+
+	var f = q.Field
+
+	var x *field.Element
+	if x, err = f.Rand(rand.Reader); err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+
+	var v, w, y, tx = q.Evaluate(x)
+
+	var a, b, c = f.Zero(), f.Zero(), f.Zero()
+
+	var i int
+	for i = range witness {
+		a.Add(a, new(field.Element).Mul(v[i], witness[i]))
+		b.Add(b, new(field.Element).Mul(w[i], witness[i]))
+		c.Add(c, new(field.Element).Mul(y[i], witness[i]))
+	}
+
+	var left = new(field.Element).Mul(a, b)
+	left.Sub(left, c)
+
+	if !left.Equal(new(field.Element).Mul(h.Evaluate(x), tx)) {
+		t.Errorf("v(x) * w(x) - y(x) != h(x) * t(x)")
+	}
+}
+
+func TestCompileNTTLarge(t *testing.T) {
+
+	var err error
+
+	if testing.Short() {
+		t.Skip("large domain")
+	}
+
+	// 200 constraints need the 5743 points of the smallest domain past 96,
+	// and 10000 twice as many, both transformed with Bluestein's algorithm.
+	var cases = []struct {
+		constraints, size int
+	}{
+		{200, 5743},
+		{10000, 2 * 5743},
+	}
+
+	var f = field.New(bn256.Order)
+
+	var d = cases[0]
+	for _, d = range cases {
+
+		var r1cs, witness = squaring(f, d.constraints)
+
+		var q *QAP
+		if q, err = CompileNTT(r1cs); err != nil {
+			t.Fatalf("compilation failed: %v", err)
+		}
+
+		if q.Domain.Size != d.size {
+			t.Fatalf("domain of %d points, expected %d", q.Domain.Size, d.size)
+		}
+
+		var h *Polynomial
+		if h, err = q.Quotient(witness); err != nil {
+			t.Fatalf("quotient failed: %v", err)
+		}
+
+		checkQuotient(t, q, witness, h)
+	}
+}
+
+func BenchmarkQuotientNTT(b *testing.B) {
+
+	var err error
+
+	// 10000 constraints on a domain of 2 * 5743 points.
+	var r1cs, witness = squaring(field.New(bn256.Order), 10000)
+
+	var q *QAP
+	if q, err = CompileNTT(r1cs); err != nil {
+		b.Fatalf("compilation failed: %v", err)
+	}
+
+	b.ResetTimer()
+
+	var i int
+	for i = 0; i < b.N; i++ {
+		if _, err = q.Quotient(witness); err != nil {
+			b.Fatalf("quotient failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package qap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// Optimization is a R1CS reduced by Optimize, along with the sizes of the
+// system before and after.
+type Optimization struct {
+	R1CS *R1CS
+
+	// Kept maps every variable of the reduced system to its index in the
+	// original one.
+	Kept []int
+
+	ConstraintsBefore, ConstraintsAfter int
+	VariablesBefore, VariablesAfter     int
+}
+
+func (o *Optimization) String() string {
+	return fmt.Sprintf("constraints %d -> %d, variables %d -> %d",
+		o.ConstraintsBefore, o.ConstraintsAfter, o.VariablesBefore, o.VariablesAfter)
+}
+
+// Witness reduces a witness of the original system to one of the optimized
+// system. The eliminated variables are determined by the ones that are kept,
+// so a witness that satisfies the original system satisfies the reduced one.
+func (o *Optimization) Witness(witness []*field.Element) []*field.Element {
+
+	var reduced = make([]*field.Element, len(o.Kept))
+
+	var i, k int
+	for i, k = range o.Kept {
+		reduced[i] = witness[k]
+	}
+
+	return reduced
+}
+
+// Optimize returns a smaller system with the same satisfying assignments of
+// the public variables, which lowers the degree of the QAP compiled from it:
+//
+//   - A constraint whose A or B is a constant is a linear relation between the
+//     variables. A private variable of the relation is solved for and
+//     substituted into the other constraints, and the constraint is removed,
+//     e.g. 1 * ((-7) * a2 + a3 + 3 * a4) = a5 of E2R1CS. Relations between
+//     public variables alone are kept, since the verifier must check them.
+//   - The linear combinations are normalized, so constraints that only differ
+//     in the order of their terms, or by swapping A and B, are merged. A
+//     combination shared by distinct constraints is left in each of them,
+//     since naming it would take one more constraint.
+//   - Private variables that are no longer used by any constraint are removed
+//     and the others are renumbered in their original order.
+//
+// No pass adds a constraint, so the result is never larger than r, which is
+// not modified.
+func (r *R1CS) Optimize() *Optimization {
+
+	var f = r.Field
+
+	var constraints = make([]Constraint, len(r.Constraints))
+
+	// occurrences lists the constraints that use each variable.
+	var occurrences = make(map[int]map[int]bool)
+
+	var i int
+	var constraint Constraint
+
+	for i, constraint = range r.Constraints {
+
+		constraints[i] = Constraint{A: normalize(f, constraint.A), B: normalize(f, constraint.B), C: normalize(f, constraint.C)}
+		occur(occurrences, i, constraints[i])
+	}
+
+	var removed = make(map[int]bool)
+
+	// A substitution can make a constraint linear, e.g. when a factor turns
+	// out to be constant, so the constraints are scanned until none changes.
+	var changed = true
+
+	for changed {
+
+		changed = false
+
+		for i = range constraints {
+
+			if removed[i] {
+				continue
+			}
+
+			var relation, linear = constraints[i].linear(f)
+			if !linear {
+				continue
+			}
+
+			// The variables of the relation are scanned from the last one, which
+			// for circuits is usually an intermediate wire rather than an input.
+			var pivot = -1
+
+			var j int
+			for j = len(relation) - 1; j >= 0; j-- {
+				if relation[j].Index != 0 && !r.Variables[relation[j].Index].Public {
+					pivot = j
+					break
+				}
+			}
+
+			switch {
+			case len(relation) == 0:
+				removed[i] = true
+				changed = true
+				continue
+			case pivot < 0:
+				continue
+			}
+
+			// x = -(relation - c * x) / c
+			var x = relation[pivot].Index
+			var factor = new(field.Element).Inv(relation[pivot].Coeff)
+			factor.Neg(factor)
+
+			var substitute = scaleLC(f, append(append(LinearCombination(nil), relation[:pivot]...), relation[pivot+1:]...), factor)
+
+			removed[i] = true
+
+			var k int
+			for k = range occurrences[x] {
+
+				if removed[k] {
+					continue
+				}
+
+				constraints[k] = Constraint{
+					A: substituteLC(f, constraints[k].A, x, substitute),
+					B: substituteLC(f, constraints[k].B, x, substitute),
+					C: substituteLC(f, constraints[k].C, x, substitute),
+				}
+
+				occur(occurrences, k, constraints[k])
+			}
+
+			delete(occurrences, x)
+			changed = true
+		}
+	}
+
+	var kept []Constraint
+	var seen = make(map[string]bool)
+
+	for i, constraint = range constraints {
+
+		if removed[i] {
+			continue
+		}
+
+		var key = constraint.key()
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		kept = append(kept, constraint)
+	}
+
+	var used = make(map[int]bool)
+
+	for _, constraint = range kept {
+
+		var term Term
+		for _, term = range append(append(append(LinearCombination(nil), constraint.A...), constraint.B...), constraint.C...) {
+			used[term.Index] = true
+		}
+	}
+
+	var reduced = NewR1CS(f)
+	var indices = map[int]int{0: 0}
+
+	var o = &Optimization{
+		R1CS:              reduced,
+		Kept:              []int{0},
+		ConstraintsBefore: len(r.Constraints),
+		VariablesBefore:   len(r.Variables),
+	}
+
+	var k int
+	for k = 1; k < len(r.Variables); k++ {
+		if r.Variables[k].Public || used[k] {
+			indices[k] = reduced.AddVariable(r.Variables[k].Name, r.Variables[k].Public)
+			o.Kept = append(o.Kept, k)
+		}
+	}
+
+	for _, constraint = range kept {
+		reduced.AddConstraint(
+			renumber(constraint.A, indices),
+			renumber(constraint.B, indices),
+			renumber(constraint.C, indices),
+		)
+	}
+
+	o.ConstraintsAfter = len(reduced.Constraints)
+	o.VariablesAfter = len(reduced.Variables)
+
+	return o
+}
+
+// linear returns the relation k * B - C = 0 if A is the constant k, or
+// k * A - C = 0 if B is. The combinations must be normalized.
+func (c Constraint) linear(f *field.Field) (LinearCombination, bool) {
+
+	var relation LinearCombination
+
+	switch {
+	case isConstant(c.A):
+		relation = scaleLC(f, c.B, constant(f, c.A))
+	case isConstant(c.B):
+		relation = scaleLC(f, c.A, constant(f, c.B))
+	default:
+		return nil, false
+	}
+
+	return normalize(f, append(relation, scaleLC(f, c.C, f.NewInt64(-1))...)), true
+}
+
+// key identifies a constraint up to the order of A and B.
+func (c Constraint) key() string {
+
+	var a, b = c.A.key(), c.B.key()
+	if a > b {
+		a, b = b, a
+	}
+
+	return a + " * " + b + " = " + c.C.key()
+}
+
+// key spells out a normalized combination.
+func (lc LinearCombination) key() string {
+
+	var terms = make([]string, len(lc))
+
+	var i int
+	var term Term
+
+	for i, term = range lc {
+		terms[i] = fmt.Sprintf("%s * a%d", term.Coeff, term.Index)
+	}
+
+	return "(" + strings.Join(terms, " + ") + ")"
+}
+
+// normalize merges the terms of the same variable, drops those with a zero
+// coefficient and sorts the rest by index.
+func normalize(f *field.Field, lc LinearCombination) LinearCombination {
+
+	var coeffs = make(map[int]*field.Element)
+
+	var term Term
+	for _, term = range lc {
+
+		if coeffs[term.Index] == nil {
+			coeffs[term.Index] = f.Zero()
+		}
+
+		coeffs[term.Index].Add(coeffs[term.Index], term.Coeff)
+	}
+
+	var normalized LinearCombination
+
+	var index int
+	var coeff *field.Element
+
+	for index, coeff = range coeffs {
+		if !coeff.IsZero() {
+			normalized = append(normalized, Term{Index: index, Coeff: coeff})
+		}
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i].Index < normalized[j].Index
+	})
+
+	return normalized
+}
+
+func isConstant(lc LinearCombination) bool {
+
+	var term Term
+	for _, term = range lc {
+		if term.Index != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// constant returns the value of a combination of the constant variable.
+func constant(f *field.Field, lc LinearCombination) *field.Element {
+	return lc.Evaluate(f, []*field.Element{f.One()})
+}
+
+func scaleLC(f *field.Field, lc LinearCombination, x *field.Element) LinearCombination {
+
+	var scaled = make(LinearCombination, 0, len(lc))
+
+	var term Term
+	for _, term = range lc {
+		scaled = append(scaled, Term{Index: term.Index, Coeff: new(field.Element).Mul(x, term.Coeff)})
+	}
+
+	return normalize(f, scaled)
+}
+
+// substituteLC replaces the variable x of the combination with substitute.
+func substituteLC(f *field.Field, lc LinearCombination, x int, substitute LinearCombination) LinearCombination {
+
+	var replaced LinearCombination
+
+	var term Term
+	for _, term = range lc {
+
+		if term.Index != x {
+			replaced = append(replaced, term)
+			continue
+		}
+
+		replaced = append(replaced, scaleLC(f, substitute, term.Coeff)...)
+	}
+
+	return normalize(f, replaced)
+}
+
+// occur records the variables of the constraint at index i.
+func occur(occurrences map[int]map[int]bool, i int, c Constraint) {
+
+	var lc LinearCombination
+	for _, lc = range []LinearCombination{c.A, c.B, c.C} {
+
+		var term Term
+		for _, term = range lc {
+
+			if occurrences[term.Index] == nil {
+				occurrences[term.Index] = make(map[int]bool)
+			}
+
+			occurrences[term.Index][i] = true
+		}
+	}
+}
+
+func renumber(lc LinearCombination, indices map[int]int) LinearCombination {
+
+	var renumbered = make(LinearCombination, len(lc))
+
+	var i int
+	var term Term
+
+	for i, term = range lc {
+		renumbered[i] = Term{Index: indices[term.Index], Coeff: term.Coeff}
+	}
+
+	return renumbered
+}
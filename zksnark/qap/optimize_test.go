@@ -0,0 +1,215 @@
+package qap
+
+import (
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestOptimize(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = e2R1CS(f)
+	var o = r1cs.Optimize()
+
+	if o.ConstraintsBefore != 2 || o.ConstraintsAfter != 1 || o.VariablesBefore != 6 || o.VariablesAfter != 5 {
+		t.Errorf("optimization %s, expected constraints 2 -> 1, variables 6 -> 5", o)
+	}
+
+	var witness = o.Witness(s)
+
+	if err = o.R1CS.IsSatisfied(witness); err != nil {
+		t.Fatalf("expected the reduced witness to satisfy the constraints: %v", err)
+	}
+
+	// The relation is solved for a4, the last private variable, and the
+	// public a5 is kept although it is no longer used.
+	var ok bool
+
+	if _, ok = o.R1CS.Variable("a4"); ok {
+		t.Errorf("expected a4 to be eliminated")
+	}
+
+	var public = o.R1CS.Public()
+	if len(public) != 2 || o.R1CS.Variables[public[1]].Name != "a5" {
+		t.Errorf("public variables = %v, expected one and a5", public)
+	}
+
+	// 4 * 3 * 2 = 24 != 25
+	witness[3] = f.NewInt64(25)
+
+	if o.R1CS.IsSatisfied(witness) == nil {
+		t.Errorf("expected a wrong product not to satisfy the reduced constraints")
+	}
+
+	var q *QAP
+	if q, err = Compile(o.R1CS, randomRoots(f, o.ConstraintsAfter)); err != nil {
+		t.Fatalf("compilation failed: %v", err)
+	}
+
+	if q.T.Degree() != 1 {
+		t.Errorf("degree of t(x) = %d, expected 1", q.T.Degree())
+	}
+}
+
+func TestOptimizeCascade(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs = NewR1CS(f)
+
+	var x = r1cs.AddVariable("x", false)
+	var y = r1cs.AddVariable("y", false)
+	var z = r1cs.AddVariable("z", false)
+	r1cs.AddVariable("unused", false)
+	var out = r1cs.AddVariable("out", true)
+	var in = r1cs.AddVariable("in", true)
+
+	// x * y = z, where y = 2 is only known from the last constraint, so the
+	// first one becomes linear too once y is substituted.
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(x, 1)},
+		LinearCombination{r1cs.NewTerm(y, 1)},
+		LinearCombination{r1cs.NewTerm(z, 1)},
+	)
+
+	// z * z = out, twice with the terms of A and B in another order.
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(z, 1)},
+		LinearCombination{r1cs.NewTerm(z, 2), r1cs.NewTerm(z, -1)},
+		LinearCombination{r1cs.NewTerm(out, 1)},
+	)
+
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(z, 1)},
+		LinearCombination{r1cs.NewTerm(z, 1)},
+		LinearCombination{r1cs.NewTerm(out, 1)},
+	)
+
+	// 1 * in = 3 only involves a public variable, so it is kept.
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(0, 1)},
+		LinearCombination{r1cs.NewTerm(in, 1)},
+		LinearCombination{r1cs.NewTerm(0, 3)},
+	)
+
+	// 1 * y = 2
+	r1cs.AddConstraint(
+		LinearCombination{r1cs.NewTerm(0, 1)},
+		LinearCombination{r1cs.NewTerm(y, 1)},
+		LinearCombination{r1cs.NewTerm(0, 2)},
+	)
+
+	var s = []*field.Element{
+		f.NewInt64(1), f.NewInt64(5), f.NewInt64(2), f.NewInt64(10), f.NewInt64(42), f.NewInt64(100), f.NewInt64(3),
+	}
+
+	if err = r1cs.IsSatisfied(s); err != nil {
+		t.Fatalf("expected witness to satisfy the constraints: %v", err)
+	}
+
+	var o = r1cs.Optimize()
+
+	// The square and the check of in are left, over one, x, out and in.
+	if o.ConstraintsAfter != 2 || o.VariablesAfter != 4 {
+		t.Errorf("optimization %s, expected constraints 5 -> 2, variables 7 -> 4", o)
+	}
+
+	var ok bool
+
+	var name string
+	for _, name = range []string{"y", "z", "unused"} {
+		if _, ok = o.R1CS.Variable(name); ok {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+
+	if err = o.R1CS.IsSatisfied(o.Witness(s)); err != nil {
+		t.Errorf("expected the reduced witness to satisfy the constraints: %v", err)
+	}
+
+	if len(r1cs.Constraints) != 5 || len(r1cs.Variables) != 7 {
+		t.Errorf("expected the original system to be unchanged")
+	}
+}
+
+func TestOptimizeShared(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs = NewR1CS(f)
+
+	var sum LinearCombination
+
+	var name string
+	for _, name = range []string{"a", "b", "c", "d", "e"} {
+		sum = append(sum, r1cs.NewTerm(r1cs.AddVariable(name, false), 1))
+	}
+
+	var x = r1cs.AddVariable("x", false)
+	var y = r1cs.AddVariable("y", false)
+	var out1 = r1cs.AddVariable("out1", true)
+	var out2 = r1cs.AddVariable("out2", true)
+
+	// (a + b + c + d + e) * x = out1 and (a + b + c + d + e) * y = out2 are
+	// distinct constraints which share their A, and both stay.
+	r1cs.AddConstraint(sum, LinearCombination{r1cs.NewTerm(x, 1)}, LinearCombination{r1cs.NewTerm(out1, 1)})
+	r1cs.AddConstraint(sum, LinearCombination{r1cs.NewTerm(y, 1)}, LinearCombination{r1cs.NewTerm(out2, 1)})
+
+	var s = []*field.Element{f.One()}
+
+	var i int
+	for i = 1; i <= 7; i++ {
+		s = append(s, f.NewInt64(int64(i)))
+	}
+
+	// a + ... + e = 15, x = 6 and y = 7
+	s = append(s, f.NewInt64(90), f.NewInt64(105))
+
+	var o = r1cs.Optimize()
+
+	if o.ConstraintsAfter != 2 || o.VariablesAfter != 10 {
+		t.Errorf("optimization %s, expected constraints 2 -> 2, variables 10 -> 10", o)
+	}
+
+	if err = o.R1CS.IsSatisfied(o.Witness(s)); err != nil {
+		t.Errorf("expected the reduced witness to satisfy the constraints: %v", err)
+	}
+}
+
+func TestOptimizeNeverGrows(t *testing.T) {
+
+	var f = field.New(bn256.Order)
+
+	var e2, _ = e2R1CS(f)
+	var e3, _ = Cubic(f)
+
+	var systems = []*R1CS{e2, e3, e2.Strengthen(), e3.Strengthen()}
+
+	var i int
+	var r1cs *R1CS
+
+	for i, r1cs = range systems {
+
+		var o = r1cs.Optimize()
+
+		if o.ConstraintsAfter > o.ConstraintsBefore || o.VariablesAfter > o.VariablesBefore {
+			t.Errorf("system %d: optimization %s grows the system", i, o)
+		}
+
+		// Optimizing again finds nothing left to remove.
+		var again = o.R1CS.Optimize()
+
+		if again.ConstraintsAfter != o.ConstraintsAfter {
+			t.Errorf("system %d: optimizing twice gives %s after %s", i, again, o)
+		}
+	}
+}
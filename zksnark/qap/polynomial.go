@@ -0,0 +1,269 @@
+package qap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// ErrDivisionByZero is returned when dividing by the zero polynomial.
+var ErrDivisionByZero = errors.New("division by the zero polynomial")
+
+// Polynomial is a polynomial over a field in coefficient form, with the
+// constant term first. Leading zero coefficients are trimmed, so the zero
+// polynomial has no coefficients. The operations return new polynomials and
+// never modify their arguments.
+type Polynomial struct {
+	field  *field.Field
+	coeffs []*field.Element
+}
+
+// NewPolynomial creates the polynomial c0 + c1 * x + c2 * x^2 + ...
+func NewPolynomial(f *field.Field, coeffs ...*field.Element) *Polynomial {
+
+	var p = &Polynomial{field: f, coeffs: make([]*field.Element, len(coeffs))}
+
+	var k int
+	for k = range coeffs {
+		p.coeffs[k] = new(field.Element).Set(coeffs[k])
+	}
+
+	return p.trim()
+}
+
+// Vanishing creates t(x) = (x - r1) * (x - r2) * ..., the monic polynomial
+// which is zero exactly at the roots.
+func Vanishing(f *field.Field, roots ...*field.Element) *Polynomial {
+
+	var coeffs = []*field.Element{f.One()}
+
+	var root *field.Element
+	for _, root = range roots {
+
+		var product = make([]*field.Element, len(coeffs)+1)
+
+		product[0] = f.Zero()
+
+		var k int
+		for k = range coeffs {
+			product[k+1] = new(field.Element).Set(coeffs[k])
+			product[k].Sub(product[k], new(field.Element).Mul(root, coeffs[k]))
+		}
+
+		coeffs = product
+	}
+
+	return &Polynomial{field: f, coeffs: coeffs}
+}
+
+// Field returns the field of the coefficients.
+func (p *Polynomial) Field() *field.Field {
+	return p.field
+}
+
+// Coefficients returns the coefficients with the constant term first. The
+// slice must not be modified.
+func (p *Polynomial) Coefficients() []*field.Element {
+	return p.coeffs
+}
+
+// Coefficient returns the coefficient of x^k, which is zero past the degree.
+func (p *Polynomial) Coefficient(k int) *field.Element {
+
+	if k < len(p.coeffs) {
+		return p.coeffs[k]
+	}
+
+	return p.field.Zero()
+}
+
+// Degree returns the degree of the polynomial, or -1 for the zero polynomial.
+func (p *Polynomial) Degree() int {
+	return len(p.coeffs) - 1
+}
+
+// IsZero reports whether all coefficients are zero.
+func (p *Polynomial) IsZero() bool {
+	return len(p.coeffs) == 0
+}
+
+// Equal reports whether two polynomials have the same coefficients.
+func (p *Polynomial) Equal(q *Polynomial) bool {
+
+	if len(p.coeffs) != len(q.coeffs) {
+		return false
+	}
+
+	var k int
+	for k = range p.coeffs {
+		if !p.coeffs[k].Equal(q.coeffs[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Add returns p + q.
+func (p *Polynomial) Add(q *Polynomial) *Polynomial {
+
+	var sum = &Polynomial{field: p.field, coeffs: make([]*field.Element, max(len(p.coeffs), len(q.coeffs)))}
+
+	var k int
+	for k = range sum.coeffs {
+		sum.coeffs[k] = new(field.Element).Add(p.Coefficient(k), q.Coefficient(k))
+	}
+
+	return sum.trim()
+}
+
+// Sub returns p - q.
+func (p *Polynomial) Sub(q *Polynomial) *Polynomial {
+
+	var difference = &Polynomial{field: p.field, coeffs: make([]*field.Element, max(len(p.coeffs), len(q.coeffs)))}
+
+	var k int
+	for k = range difference.coeffs {
+		difference.coeffs[k] = new(field.Element).Sub(p.Coefficient(k), q.Coefficient(k))
+	}
+
+	return difference.trim()
+}
+
+// Scale returns c * p.
+func (p *Polynomial) Scale(c *field.Element) *Polynomial {
+
+	var scaled = &Polynomial{field: p.field, coeffs: make([]*field.Element, len(p.coeffs))}
+
+	var k int
+	for k = range p.coeffs {
+		scaled.coeffs[k] = new(field.Element).Mul(p.coeffs[k], c)
+	}
+
+	return scaled.trim()
+}
+
+// Mul returns p * q with schoolbook multiplication.
+func (p *Polynomial) Mul(q *Polynomial) *Polynomial {
+
+	if p.IsZero() || q.IsZero() {
+		return NewPolynomial(p.field)
+	}
+
+	var product = &Polynomial{field: p.field, coeffs: make([]*field.Element, len(p.coeffs)+len(q.coeffs)-1)}
+
+	var i, j int
+	for i = range product.coeffs {
+		product.coeffs[i] = p.field.Zero()
+	}
+
+	for i = range p.coeffs {
+		for j = range q.coeffs {
+			product.coeffs[i+j].Add(product.coeffs[i+j], new(field.Element).Mul(p.coeffs[i], q.coeffs[j]))
+		}
+	}
+
+	return product.trim()
+}
+
+// DivMod computes the quotient and remainder of p / q with long division, so
+// that p = quotient * q + remainder and the degree of the remainder is less
+// than the degree of q.
+func (p *Polynomial) DivMod(q *Polynomial) (*Polynomial, *Polynomial, error) {
+
+	if q.IsZero() {
+		return nil, nil, ErrDivisionByZero
+	}
+
+	if p.Degree() < q.Degree() {
+		return NewPolynomial(p.field), NewPolynomial(p.field, p.coeffs...), nil
+	}
+
+	var remainder = make([]*field.Element, len(p.coeffs))
+	var quotient = make([]*field.Element, p.Degree()-q.Degree()+1)
+
+	var k int
+	for k = range p.coeffs {
+		remainder[k] = new(field.Element).Set(p.coeffs[k])
+	}
+
+	var lead = new(field.Element).Inv(q.coeffs[q.Degree()])
+
+	for k = len(quotient) - 1; k >= 0; k-- {
+
+		// Cancel the leading term of the remainder with a multiple of q.
+		quotient[k] = new(field.Element).Mul(remainder[k+q.Degree()], lead)
+
+		var j int
+		for j = range q.coeffs {
+			remainder[k+j].Sub(remainder[k+j], new(field.Element).Mul(quotient[k], q.coeffs[j]))
+		}
+	}
+
+	var r = &Polynomial{field: p.field, coeffs: remainder[:q.Degree()]}
+
+	return (&Polynomial{field: p.field, coeffs: quotient}).trim(), r.trim(), nil
+}
+
+// Evaluate computes p(x) with Horner's method.
+func (p *Polynomial) Evaluate(x *field.Element) *field.Element {
+
+	var result = p.field.Zero()
+
+	var k int
+	for k = len(p.coeffs) - 1; k >= 0; k-- {
+		result.Mul(result, x)
+		result.Add(result, p.coeffs[k])
+	}
+
+	return result
+}
+
+func (p *Polynomial) String() string {
+
+	if p.IsZero() {
+		return "0"
+	}
+
+	var terms []string
+
+	var k int
+	for k = len(p.coeffs) - 1; k >= 0; k-- {
+
+		if p.coeffs[k].IsZero() {
+			continue
+		}
+
+		switch k {
+		case 0:
+			terms = append(terms, p.coeffs[k].String())
+		case 1:
+			terms = append(terms, fmt.Sprintf("%s * x", p.coeffs[k]))
+		default:
+			terms = append(terms, fmt.Sprintf("%s * x^%d", p.coeffs[k], k))
+		}
+	}
+
+	return strings.Join(terms, " + ")
+}
+
+// trim drops leading zero coefficients in place.
+func (p *Polynomial) trim() *Polynomial {
+
+	for len(p.coeffs) > 0 && p.coeffs[len(p.coeffs)-1].IsZero() {
+		p.coeffs = p.coeffs[:len(p.coeffs)-1]
+	}
+
+	return p
+}
+
+func max(a, b int) int {
+
+	if a > b {
+		return a
+	}
+
+	return b
+}
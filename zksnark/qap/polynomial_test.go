@@ -0,0 +1,85 @@
+package qap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestPolynomialArithmetic(t *testing.T) {
+
+	var f = field.New(big.NewInt(23))
+
+	// p(x) = 1 + 2x + 3x^2, q(x) = 4 + x
+	var p = NewPolynomial(f, f.NewInt64(1), f.NewInt64(2), f.NewInt64(3))
+	var q = NewPolynomial(f, f.NewInt64(4), f.NewInt64(1))
+
+	var cases = []struct {
+		name     string
+		actual   *Polynomial
+		expected []int64
+	}{
+		{"p + q", p.Add(q), []int64{5, 3, 3}},
+		{"p - q", p.Sub(q), []int64{-3, 1, 3}},
+		{"p - p", p.Sub(p), []int64{}},
+		{"p * q", p.Mul(q), []int64{4, 9, 14, 3}},
+		{"5 * q", q.Scale(f.NewInt64(5)), []int64{20, 5}},
+		{"(x - 2)(x - 3)", Vanishing(f, f.NewInt64(2), f.NewInt64(3)), []int64{6, -5, 1}},
+	}
+
+	var c = cases[0]
+	for _, c = range cases {
+
+		var coeffs []*field.Element
+
+		var k int
+		for k = range c.expected {
+			coeffs = append(coeffs, f.NewInt64(c.expected[k]))
+		}
+
+		if !c.actual.Equal(NewPolynomial(f, coeffs...)) {
+			t.Errorf("%s = %s, expected %v", c.name, c.actual, c.expected)
+		}
+	}
+
+	if p.Degree() != 2 || p.Sub(p).Degree() != -1 {
+		t.Errorf("unexpected degrees %d and %d", p.Degree(), p.Sub(p).Degree())
+	}
+
+	// p(5) = 1 + 10 + 75 = 86 = 17 (mod 23)
+	if !p.Evaluate(f.NewInt64(5)).Equal(f.NewInt64(17)) {
+		t.Errorf("p(5) = %d, expected 17", p.Evaluate(f.NewInt64(5)))
+	}
+}
+
+func TestPolynomialDivMod(t *testing.T) {
+
+	var err error
+
+	var f = field.New(big.NewInt(997))
+
+	var p = NewPolynomial(f, f.NewInt64(1), f.NewInt64(2), f.NewInt64(3))
+	var q = NewPolynomial(f, f.NewInt64(4), f.NewInt64(1))
+
+	var product = p.Mul(q).Add(NewPolynomial(f, f.NewInt64(7)))
+
+	var quotient, remainder *Polynomial
+	if quotient, remainder, err = product.DivMod(q); err != nil {
+		t.Fatalf("division failed: %v", err)
+	}
+
+	if !quotient.Equal(p) || !remainder.Equal(NewPolynomial(f, f.NewInt64(7))) {
+		t.Errorf("quotient = %s, remainder = %s, expected %s and 7", quotient, remainder, p)
+	}
+
+	var t1 = Vanishing(f, f.NewInt64(3), f.NewInt64(7), f.NewInt64(11))
+
+	if quotient, remainder, err = t1.Mul(p).DivMod(t1); err != nil || !quotient.Equal(p) || !remainder.IsZero() {
+		t.Errorf("expected t(x) * p(x) to be divisible by t(x)")
+	}
+
+	if _, _, err = p.DivMod(NewPolynomial(f)); err != ErrDivisionByZero {
+		t.Errorf("expected %v, got %v", ErrDivisionByZero, err)
+	}
+}
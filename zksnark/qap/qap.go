@@ -1,54 +1,95 @@
 package qap
 
 import (
-	"math/big"
+	"errors"
+
+	"github.com/eugenekadish/cryptopalooza/field"
 )
 
-// BasisPolynomial generates a Lagrange basis polynomial modulo the order of the field.
-func BasisPolynomial(order *big.Int, j int, xCoords ...*big.Int) func(*big.Int) *big.Int {
+// ErrIndexOutOfRange is returned when the index of a basis polynomial is not
+// the index of one of the x-coordinates.
+var ErrIndexOutOfRange = errors.New("basis index out of range")
 
-	// TODO: Better error handling for index out of range, dividing by zero, etc.
+// ErrDuplicateCoordinate is returned when two x-coordinates are the same
+// element of the field, so the Lagrange basis is not defined.
+var ErrDuplicateCoordinate = errors.New("x-coordinates must be distinct")
 
-	var selected = xCoords[j]
-	var denominator = big.NewInt(1)
+// ErrNotInvertible is returned when the denominator of a basis polynomial has
+// no inverse, which can only happen when the modulus is not prime.
+var ErrNotInvertible = errors.New("denominator is not invertible")
+
+// ErrLengthMismatch is returned when the number of y-coordinates does not
+// match the number of basis polynomials.
+var ErrLengthMismatch = errors.New("number of y-coordinates does not match the number of basis polynomials")
 
-	xCoords = append(xCoords[:j], xCoords[j+1:]...)
+// BasisPolynomial generates a Lagrange basis polynomial over the field. The
+// x-coordinates are not modified, so the same slice can be passed for every
+// index.
+func BasisPolynomial(f *field.Field, j int, xCoords ...*field.Element) (func(*field.Element) *field.Element, error) {
 
-	var xCoord *big.Int
-	for _, xCoord = range xCoords {
-		denominator = new(big.Int).Mul(denominator, new(big.Int).Sub(selected, xCoord))
+	if j < 0 || j >= len(xCoords) {
+		return nil, ErrIndexOutOfRange
 	}
 
-	// TODO: Include the order of the field as a function parameter.
-	return func(x *big.Int) *big.Int {
+	var selected = xCoords[j]
+	var denominator = f.One()
+
+	var others = make([]*field.Element, 0, len(xCoords)-1)
 
-		var numerator = big.NewInt(1)
+	var k int
+	var xCoord *field.Element
 
-		var xCoord *big.Int
-		for _, xCoord = range xCoords {
-			numerator = new(big.Int).Mul(numerator, new(big.Int).Sub(x, xCoord))
+	for k, xCoord = range xCoords {
+
+		if k == j {
+			continue
+		}
+
+		if xCoord.Equal(selected) {
+			return nil, ErrDuplicateCoordinate
 		}
 
-		// return new(big.Int).Mul(numerator, new(big.Int).ModInverse(denominator, order))
-		return new(big.Int).Mod(new(big.Int).Mul(numerator, new(big.Int).ModInverse(denominator, order)), order)
+		others = append(others, xCoord)
+		denominator.Mul(denominator, new(field.Element).Sub(selected, xCoord))
 	}
+
+	if denominator.Inv(denominator) == nil {
+		return nil, ErrNotInvertible
+	}
+
+	return func(x *field.Element) *field.Element {
+
+		var numerator = f.One()
+
+		var xCoord *field.Element
+		for _, xCoord = range others {
+			numerator.Mul(numerator, new(field.Element).Sub(x, xCoord))
+		}
+
+		return numerator.Mul(numerator, denominator)
+	}, nil
 }
 
 // Interpolate loops through the basis polynomials and y-coordinates for evaluating at a point.
 func Interpolate(
-	x *big.Int, yCoords []int64, basis ...func(*big.Int) *big.Int,
-) *big.Int {
+	x *field.Element, yCoords []int64, basis ...func(*field.Element) *field.Element,
+) (*field.Element, error) {
 
-	// TODO: Use Fast Fourier transform (FFT) for polynomial interpolation
+	// NOTE: For points forming a subgroup, Domain.InterpolateNTT interpolates
+	// with the number theoretic transform.
+
+	if len(yCoords) != len(basis) {
+		return nil, ErrLengthMismatch
+	}
 
-	var accumulator = big.NewInt(0)
+	var accumulator = x.Field().Zero()
 
 	var index int
-	var base func(*big.Int) *big.Int
+	var base func(*field.Element) *field.Element
 
 	for index, base = range basis {
-		accumulator = new(big.Int).Add(accumulator, new(big.Int).Mul(big.NewInt(yCoords[index]), base(x)))
+		accumulator.Add(accumulator, new(field.Element).Mul(x.Field().NewInt64(yCoords[index]), base(x)))
 	}
 
-	return accumulator
+	return accumulator, nil
 }
@@ -9,138 +9,107 @@ import (
 	"testing"
 
 	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
 )
 
 func TestBasisPolynomial(t *testing.T) {
 
-	var order = bn256.Order.Set(big.NewInt(23))
-
-	var xCoords []*big.Int
-	var l []func(*big.Int) *big.Int
-
-	xCoords = []*big.Int{}
-
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(4))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(8))
-
-	l = append(l, BasisPolynomial(order, 0, xCoords...))
-
-	fmt.Printf(" - l_{0}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[0](big.NewInt(2)), order)) // Should be 1
-	fmt.Printf(" - l_{0}(xCoords[1] == 4) = %d \n", new(big.Int).Mod(l[0](big.NewInt(4)), order)) // Should be 0
-	fmt.Printf(" - l_{0}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[0](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{0}(xCoords[3] == 8) = %d \n", new(big.Int).Mod(l[0](big.NewInt(8)), order)) // Should be 0
+	var err error
 
-	xCoords = []*big.Int{}
+	var f = field.New(big.NewInt(23))
 
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(4))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(8))
+	var xCoords = []*field.Element{f.NewInt64(2), f.NewInt64(4), f.NewInt64(6), f.NewInt64(8)}
 
-	l = append(l, BasisPolynomial(order, 1, xCoords...))
+	var j, k int
+	for j = range xCoords {
 
-	fmt.Printf(" - l_{1}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[1](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{1}(xCoords[1] == 4) = %d \n", new(big.Int).Mod(l[1](big.NewInt(4)), order)) // Should be 1
-	fmt.Printf(" - l_{1}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[1](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{1}(xCoords[3] == 8) = %d \n", new(big.Int).Mod(l[1](big.NewInt(8)), order)) // Should be 0
+		var l func(*field.Element) *field.Element
+		if l, err = BasisPolynomial(f, j, xCoords...); err != nil {
+			t.Fatalf("basis polynomial %d: %v", j, err)
+		}
 
-	xCoords = []*big.Int{}
+		// l_{j}(xCoords[k]) is 1 when j == k and 0 otherwise.
+		for k = range xCoords {
 
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(4))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(8))
+			var expected = f.Zero()
+			if j == k {
+				expected = f.One()
+			}
 
-	l = append(l, BasisPolynomial(order, 2, xCoords...))
+			var actual = l(xCoords[k])
+			if !actual.Equal(expected) {
+				t.Errorf("l_{%d}(%d) = %d, expected %d", j, xCoords[k], actual, expected)
+			}
+		}
+	}
 
-	fmt.Printf(" - l_{2}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[2](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{2}(xCoords[1] == 4) = %d \n", new(big.Int).Mod(l[2](big.NewInt(4)), order)) // Should be 0
-	fmt.Printf(" - l_{2}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[2](big.NewInt(6)), order)) // Should be 1
-	fmt.Printf(" - l_{2}(xCoords[3] == 8) = %d \n", new(big.Int).Mod(l[2](big.NewInt(8)), order)) // Should be 0
+	// The shared slice is not modified by removing the selected coordinate.
+	for k = range xCoords {
+		if !xCoords[k].Equal(f.NewInt64(int64(2 * (k + 1)))) {
+			t.Errorf("xCoords[%d] = %d, expected %d", k, xCoords[k], 2*(k+1))
+		}
+	}
 
-	xCoords = []*big.Int{}
+	var cases = []struct {
+		name     string
+		j        int
+		xCoords  []*field.Element
+		expected error
+	}{
+		{"negative index", -1, xCoords, ErrIndexOutOfRange},
+		{"index past the end", 4, xCoords, ErrIndexOutOfRange},
+		{"no coordinates", 0, nil, ErrIndexOutOfRange},
+		{"duplicate coordinate", 1, []*field.Element{f.NewInt64(2), f.NewInt64(4), f.NewInt64(27)}, ErrDuplicateCoordinate},
+	}
 
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(4))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(8))
+	var c = cases[0]
+	for _, c = range cases {
+		if _, err = BasisPolynomial(f, c.j, c.xCoords...); err != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
 
-	l = append(l, BasisPolynomial(order, 3, xCoords...))
+	// Over Z/15 the difference 8 - 2 = 6 is not a unit.
+	var g = field.New(big.NewInt(15))
 
-	fmt.Printf(" - l_{3}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[3](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[1] == 4) = %d \n", new(big.Int).Mod(l[3](big.NewInt(4)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[3](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[3] == 8) = %d \n", new(big.Int).Mod(l[3](big.NewInt(8)), order)) // Should be 1
+	if _, err = BasisPolynomial(g, 0, g.NewInt64(8), g.NewInt64(2)); err != ErrNotInvertible {
+		t.Errorf("expected %v, got %v", ErrNotInvertible, err)
+	}
 }
 
 func TestInterpolation(t *testing.T) {
 
-	var order = bn256.Order.Set(big.NewInt(11))
-
-	var xCoords []*big.Int
-	var l []func(*big.Int) *big.Int
-
-	xCoords = []*big.Int{}
-
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(5))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(9))
-
-	l = append(l, BasisPolynomial(order, 0, xCoords...))
-
-	fmt.Printf(" - l_{0}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[0](big.NewInt(2)), order)) // Should be 1
-	fmt.Printf(" - l_{0}(xCoords[1] == 5) = %d \n", new(big.Int).Mod(l[0](big.NewInt(5)), order)) // Should be 0
-	fmt.Printf(" - l_{0}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[0](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{0}(xCoords[2] == 9) = %d \n", new(big.Int).Mod(l[0](big.NewInt(9)), order)) // Should be 0
-
-	xCoords = []*big.Int{}
-
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(5))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(9))
-
-	l = append(l, BasisPolynomial(order, 1, xCoords...))
-
-	fmt.Printf(" - l_{1}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[0](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{1}(xCoords[1] == 5) = %d \n", new(big.Int).Mod(l[0](big.NewInt(5)), order)) // Should be 1
-	fmt.Printf(" - l_{1}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[0](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{1}(xCoords[2] == 9) = %d \n", new(big.Int).Mod(l[0](big.NewInt(9)), order)) // Should be 0
-
-	xCoords = []*big.Int{}
-
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(5))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(9))
+	var err error
 
-	l = append(l, BasisPolynomial(order, 2, xCoords...))
+	var f = field.New(big.NewInt(11))
 
-	fmt.Printf(" - l_{2}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[0](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{2}(xCoords[1] == 5) = %d \n", new(big.Int).Mod(l[0](big.NewInt(5)), order)) // Should be 0
-	fmt.Printf(" - l_{2}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[0](big.NewInt(6)), order)) // Should be 1
-	fmt.Printf(" - l_{2}(xCoords[2] == 9) = %d \n", new(big.Int).Mod(l[0](big.NewInt(9)), order)) // Should be 0
+	var xCoords = []*field.Element{f.NewInt64(2), f.NewInt64(5), f.NewInt64(6), f.NewInt64(9)}
+	var yCoords = []int64{2, 7, 1, 3}
 
-	xCoords = []*big.Int{}
+	var l = make([]func(*field.Element) *field.Element, len(xCoords))
 
-	xCoords = append(xCoords, big.NewInt(2))
-	xCoords = append(xCoords, big.NewInt(5))
-	xCoords = append(xCoords, big.NewInt(6))
-	xCoords = append(xCoords, big.NewInt(9))
+	var j int
+	for j = range l {
+		if l[j], err = BasisPolynomial(f, j, xCoords...); err != nil {
+			t.Fatalf("basis polynomial %d: %v", j, err)
+		}
+	}
 
-	l = append(l, BasisPolynomial(order, 3, xCoords...))
+	var eval *field.Element
+	for j = range xCoords {
 
-	fmt.Printf(" - l_{3}(xCoords[0] == 2) = %d \n", new(big.Int).Mod(l[0](big.NewInt(2)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[1] == 5) = %d \n", new(big.Int).Mod(l[0](big.NewInt(5)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[2] == 6) = %d \n", new(big.Int).Mod(l[0](big.NewInt(6)), order)) // Should be 0
-	fmt.Printf(" - l_{3}(xCoords[2] == 9) = %d \n", new(big.Int).Mod(l[0](big.NewInt(9)), order)) // Should be 1
+		if eval, err = Interpolate(xCoords[j], yCoords, l...); err != nil {
+			t.Fatalf("interpolation failed: %v", err)
+		}
 
-	var eval = Interpolate(big.NewInt(5), []int64{2, 7, 1, 3}, l...)
+		if !eval.Equal(f.NewInt64(yCoords[j])) {
+			t.Errorf("p(%d) = %d, expected %d", xCoords[j], eval, yCoords[j])
+		}
+	}
 
-	fmt.Printf(" - eval = %d \n", new(big.Int).Mod(eval, order)) // Should be 7
+	if _, err = Interpolate(f.NewInt64(5), yCoords[:3], l...); err != ErrLengthMismatch {
+		t.Errorf("expected %v, got %v", ErrLengthMismatch, err)
+	}
 }
 
 func TestBN256Pairing(*testing.T) {
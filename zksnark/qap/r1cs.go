@@ -0,0 +1,205 @@
+package qap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+// ErrWitnessLength is returned when a witness does not have exactly one value
+// for every variable of a R1CS.
+var ErrWitnessLength = errors.New("witness length does not match the number of variables")
+
+// ErrWitnessConstant is returned when the first entry of a witness, which
+// stands for the constant terms of the constraints, is not 1.
+var ErrWitnessConstant = errors.New("witness does not start with the constant 1")
+
+// Term is a single entry of a sparse row in one of the R1CS matrices, which
+// multiplies the witness value at Index by Coeff.
+type Term struct {
+	Index int
+	Coeff *field.Element
+}
+
+// LinearCombination is a sparse row of the A, B or C matrix of a R1CS.
+type LinearCombination []Term
+
+// Evaluate computes the dot product of the row with the witness.
+func (lc LinearCombination) Evaluate(f *field.Field, witness []*field.Element) *field.Element {
+
+	var accumulator = f.Zero()
+
+	var term Term
+	for _, term = range lc {
+		accumulator.Add(accumulator, new(field.Element).Mul(term.Coeff, witness[term.Index]))
+	}
+
+	return accumulator
+}
+
+// Variable is a named entry of the witness vector. Public variables are
+// revealed to the verifier, private ones are only known to the prover.
+type Variable struct {
+	Name   string
+	Public bool
+}
+
+// Constraint is a single rank-1 constraint (A . s) * (B . s) = (C . s).
+type Constraint struct {
+	A, B, C LinearCombination
+}
+
+// R1CS is a rank-1 constraint system over a field. The variable at index 0 is
+// always the constant 1, so the witness vector has the same layout as the
+// solution vector s in the examples: [1, a1, a2, ...].
+type R1CS struct {
+	Field       *field.Field
+	Variables   []Variable
+	Constraints []Constraint
+}
+
+// NewR1CS creates an empty constraint system with only the constant variable.
+func NewR1CS(f *field.Field) *R1CS {
+
+	return &R1CS{
+		Field:     f,
+		Variables: []Variable{{Name: "one", Public: true}},
+	}
+}
+
+// NewTerm is a shorthand for a term with a small (possibly negative)
+// coefficient.
+func (r *R1CS) NewTerm(index int, coeff int64) Term {
+	return Term{Index: index, Coeff: r.Field.NewInt64(coeff)}
+}
+
+// AddVariable appends a variable to the witness layout and returns its index.
+func (r *R1CS) AddVariable(name string, public bool) int {
+
+	r.Variables = append(r.Variables, Variable{Name: name, Public: public})
+
+	return len(r.Variables) - 1
+}
+
+// AddConstraint appends the constraint (a . s) * (b . s) = (c . s) and
+// returns its index.
+func (r *R1CS) AddConstraint(a, b, c LinearCombination) int {
+
+	r.Constraints = append(r.Constraints, Constraint{A: a, B: b, C: c})
+
+	return len(r.Constraints) - 1
+}
+
+// Variable looks up the index of a variable by name.
+func (r *R1CS) Variable(name string) (int, bool) {
+
+	var index int
+	var variable Variable
+
+	for index, variable = range r.Variables {
+		if variable.Name == name {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// Public lists the indices of the variables known to the verifier, which
+// always includes the constant at index 0.
+func (r *R1CS) Public() []int {
+
+	var indices []int
+
+	var index int
+	var variable Variable
+
+	for index, variable = range r.Variables {
+		if variable.Public {
+			indices = append(indices, index)
+		}
+	}
+
+	return indices
+}
+
+// Strengthen returns a copy of the constraint system with the extra
+// constraints of a strong QAP (eprint 2012/215): for every variable a_k other
+// than the constant, a_k * 1 = a_k and then 1 * a_k = a_k. Enforced at their
+// own roots r_k and s_k, they make v_k, w_k and y_k non-zero somewhere, so a
+// prover can not use different values of a_k in v(x), w(x) and y(x). The
+// roots r1, r2, s1 and s2 derived by hand above E1SQAP are the ones it adds.
+func (r *R1CS) Strengthen() *R1CS {
+
+	var strong = &R1CS{
+		Field:       r.Field,
+		Variables:   append([]Variable(nil), r.Variables...),
+		Constraints: append([]Constraint(nil), r.Constraints...),
+	}
+
+	var k int
+	for k = 1; k < len(r.Variables); k++ {
+		strong.AddConstraint(
+			LinearCombination{r.NewTerm(k, 1)},
+			LinearCombination{r.NewTerm(0, 1)},
+			LinearCombination{r.NewTerm(k, 1)},
+		)
+	}
+
+	for k = 1; k < len(r.Variables); k++ {
+		strong.AddConstraint(
+			LinearCombination{r.NewTerm(0, 1)},
+			LinearCombination{r.NewTerm(k, 1)},
+			LinearCombination{r.NewTerm(k, 1)},
+		)
+	}
+
+	return strong
+}
+
+// UnsatisfiedError reports the first constraint that does not hold for a
+// witness, along with both sides of its equation.
+type UnsatisfiedError struct {
+	Constraint int
+
+	// Left is (A . s) * (B . s) and Right is (C . s).
+	Left  *field.Element
+	Right *field.Element
+}
+
+func (e *UnsatisfiedError) Error() string {
+	return fmt.Sprintf("constraint %d is not satisfied: %d != %d", e.Constraint, e.Left, e.Right)
+}
+
+// IsSatisfied checks every constraint against the witness and returns an
+// *UnsatisfiedError for the first one that fails, or nil if all hold.
+func (r *R1CS) IsSatisfied(witness []*field.Element) error {
+
+	if len(witness) != len(r.Variables) {
+		return ErrWitnessLength
+	}
+
+	if !witness[0].IsOne() {
+		return ErrWitnessConstant
+	}
+
+	var index int
+	var constraint Constraint
+
+	for index, constraint = range r.Constraints {
+
+		var left = new(field.Element).Mul(
+			constraint.A.Evaluate(r.Field, witness),
+			constraint.B.Evaluate(r.Field, witness),
+		)
+
+		var right = constraint.C.Evaluate(r.Field, witness)
+
+		if !left.Equal(right) {
+			return &UnsatisfiedError{Constraint: index, Left: left, Right: right}
+		}
+	}
+
+	return nil
+}
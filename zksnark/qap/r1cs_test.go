@@ -0,0 +1,95 @@
+package qap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/bn256"
+	"github.com/eugenekadish/cryptopalooza/field"
+)
+
+func TestR1CSIsSatisfied(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+
+	if err = r1cs.IsSatisfied(s); err != nil {
+		t.Fatalf("expected witness to satisfy the constraints: %v", err)
+	}
+
+	// 3 * 3 * 3 + 3 + 5 = 35 != 36
+	s[4] = f.NewInt64(36)
+
+	var unsatisfied *UnsatisfiedError
+	var ok bool
+
+	if unsatisfied, ok = r1cs.IsSatisfied(s).(*UnsatisfiedError); !ok {
+		t.Fatalf("expected an unsatisfied constraint")
+	}
+
+	if unsatisfied.Constraint != 2 {
+		t.Errorf("constraint = %d, expected 2", unsatisfied.Constraint)
+	}
+
+	if !unsatisfied.Left.Equal(f.NewInt64(35)) || !unsatisfied.Right.Equal(f.NewInt64(36)) {
+		t.Errorf("left = %d, right = %d, expected 35 and 36", unsatisfied.Left, unsatisfied.Right)
+	}
+
+	if err = r1cs.IsSatisfied(s[:4]); err != ErrWitnessLength {
+		t.Errorf("expected %v, got %v", ErrWitnessLength, err)
+	}
+}
+
+func TestR1CSNegativeCoefficients(t *testing.T) {
+
+	var err error
+
+	var r1cs, s = e2R1CS(field.New(big.NewInt(997)))
+
+	if err = r1cs.IsSatisfied(s); err != nil {
+		t.Fatalf("expected witness to satisfy the constraints: %v", err)
+	}
+
+	var public = r1cs.Public()
+	if len(public) != 2 || public[0] != 0 || public[1] != 5 {
+		t.Errorf("public = %v, expected [0 5]", public)
+	}
+
+	var index int
+	var ok bool
+
+	if index, ok = r1cs.Variable("a3"); !ok || index != 3 {
+		t.Errorf("variable a3 = %d, %t, expected 3", index, ok)
+	}
+}
+
+func TestR1CSStrengthen(t *testing.T) {
+
+	var err error
+
+	var f = field.New(bn256.Order)
+
+	var r1cs, s = Cubic(f)
+	var strong = r1cs.Strengthen()
+
+	// 3 constraints and 2 for each of the variables a1, a2, a3 and a4.
+	if len(strong.Constraints) != 11 || len(r1cs.Constraints) != 3 {
+		t.Errorf("constraints = %d, %d, expected 11, 3", len(strong.Constraints), len(r1cs.Constraints))
+	}
+
+	if err = strong.IsSatisfied(s); err != nil {
+		t.Errorf("expected witness to satisfy the strong constraints: %v", err)
+	}
+
+	var examples = []func(*big.Int) bool{E1SQAP, E2SQAP, E3SQAP}
+
+	var i int
+	for i = range examples {
+		if !examples[i](bn256.Order) {
+			t.Errorf("expected strong QAP %d to pass quadratic root detection", i+1)
+		}
+	}
+}